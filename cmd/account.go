@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bernhardson/prefoot/internal/binding"
+	"github.com/bernhardson/prefoot/internal/models"
+	"github.com/bernhardson/prefoot/internal/validator"
+)
+
+const (
+	verificationTokenTTL  = 3 * 24 * time.Hour
+	passwordResetTokenTTL = 45 * time.Minute
+)
+
+func (app *application) sendVerificationMail(id int, email string) error {
+
+	token, err := app.userTokens.New(id, models.PurposeVerification, verificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/user/verify?token=%s", app.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Welcome to prefoot!\n\nPlease confirm your email address by opening the link below within the next 3 days:\n\n%s\n", link)
+	return app.mailer.Send(email, "Confirm your prefoot account", body)
+}
+
+type verifyParams struct {
+	Token string `query:"token" required:"true"`
+}
+
+// verifies the email address of the user the token was sent to
+func (app *application) userVerify(w http.ResponseWriter, r *http.Request) {
+
+	var p verifyParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	id, err := app.userTokens.Consume(p.Token, models.PurposeVerification)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			app.badRequest(w, r, "invalid or expired verification token")
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.users.SetVerified(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.userTokens.DeleteAllForUser(id, models.PurposeVerification)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("user_%d: failed to delete verification tokens", id))
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Your email address has been confirmed.")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sends a new verification link to the logged in user
+func (app *application) userVerifyResendPost(w http.ResponseWriter, r *http.Request) {
+
+	user, err := app.users.Get(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if user.Verified {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err = app.sendVerificationMail(user.ID, user.Email)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type passwordForgotForm struct {
+	Email               string `json:"email"`
+	validator.Validator `json:"-"`
+}
+
+// sends a password reset link if the email belongs to an account
+// the response is the same either way so accounts can't be enumerated
+func (app *application) userPasswordForgotPost(w http.ResponseWriter, r *http.Request) {
+
+	var form passwordForgotForm
+	err := json.NewDecoder(r.Body).Decode(&form)
+	if err != nil {
+		app.badRequest(w, r, "failed to decode JSON payload")
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	if !form.Valid() {
+		app.failedValidation(w, r, form.Validator)
+		return
+	}
+
+	user, err := app.users.GetByEmail(form.Email)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			w.WriteHeader(http.StatusAccepted)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	token, err := app.userTokens.New(user.ID, models.PurposePasswordReset, passwordResetTokenTTL)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/user/password/reset?token=%s", app.baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nsomeone asked to reset the password of your prefoot account. Open the link below within 45 minutes to choose a new one:\n\n%s\n\nIf this wasn't you, you can ignore this mail.\n", user.Name, link)
+	err = app.mailer.Send(user.Email, "Reset your prefoot password", body)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type passwordResetForm struct {
+	Token               string `json:"token"`
+	Password            string `json:"password"`
+	validator.Validator `json:"-"`
+}
+
+func (app *application) userPasswordResetPost(w http.ResponseWriter, r *http.Request) {
+
+	var form passwordResetForm
+	err := json.NewDecoder(r.Body).Decode(&form)
+	if err != nil {
+		app.badRequest(w, r, "failed to decode JSON payload")
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Token), "token", "This field cannot be blank")
+	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+	form.CheckField(validator.MinChars(form.Password, 8), "password", "This field must be at least 8 characters long")
+	if !form.Valid() {
+		app.failedValidation(w, r, form.Validator)
+		return
+	}
+
+	id, err := app.userTokens.Consume(form.Token, models.PurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidToken) {
+			form.AddFieldError("token", "Invalid or expired password reset token")
+			app.failedValidation(w, r, form.Validator)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.users.PasswordSet(id, form.Password)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// the mail proved ownership of the address as well
+	err = app.users.SetVerified(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.userTokens.DeleteAllForUser(id, models.PurposePasswordReset)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("user_%d: failed to delete password reset tokens", id))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -8,21 +8,21 @@ func (app *application) calculateStandings(w http.ResponseWriter, r *http.Reques
 
 	/* league, err := strconv.Atoi(r.URL.Query().Get("league"))
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 	}
 
 	season, err := strconv.Atoi(r.URL.Query().Get("season"))
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 	} */
 
 	/* round, err := strconv.Atoi(r.URL.Query().Get("round"))
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 	}
 
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -30,13 +30,13 @@ func (app *application) calculateStandings(w http.ResponseWriter, r *http.Reques
 	for _, f := range fixtures {
 		ht, err := app.repo.Teams.Select(f.HomeTeam)
 		if err != nil {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 			return
 		}
 
 		at, err := app.repo.Teams.Select(f.AwayTeam)
 		if err != nil {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 			return
 		}
 		fixture := fixture{Fixture: f, Home: ht, Away: at}
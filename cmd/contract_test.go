@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/bernhardson/prefoot/internal/openapi"
+	"github.com/bernhardson/prefoot/internal/ratelimit"
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/bernhardson/prefoot/pkg/fixture"
+	"github.com/bernhardson/prefoot/pkg/players"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// newTestApplication returns an application on pool that doesn't rate
+// limit, and its routes.
+func newTestApplication(pool *pgxpool.Pool) (*application, http.Handler) {
+
+	logger := zerolog.Nop()
+	app := newApplication(pool, &logger, scs.New(), &comm.Client{Logger: &logger})
+	app.limiter = ratelimit.NewMemoryStore()
+	unlimited := ratelimit.Limit{Rate: 1000, Burst: 100000}
+	app.rateLimits = rateLimits{IP: unlimited, User: unlimited, Token: unlimited}
+	return app, app.routes()
+}
+
+// requestPath fills the path parameters of path and the required query
+// parameters of its operation with values, ids are looked up by the
+// segment before them, e.g. "teams" for /teams/:id.
+func requestPath(t *testing.T, app *application, rt route, values map[string]string) string {
+
+	parts := strings.Split(rt.path, "/")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, ":") {
+			continue
+		}
+		name := part[1:]
+		if name == "id" {
+			name = parts[i-1]
+		}
+		v, ok := values[name]
+		if !ok {
+			t.Fatalf("%s %s: no value for %s", rt.method, rt.path, part)
+		}
+		parts[i] = v
+	}
+
+	query := url.Values{}
+	item := app.openapi.Paths[openapi.OpenAPIPath(rt.path)][strings.ToLower(rt.method)]
+	for _, p := range item.Parameters {
+		if p.In != "query" || !p.Required {
+			continue
+		}
+		v, ok := values[p.Name]
+		if !ok {
+			t.Fatalf("%s %s: no value for query parameter %s", rt.method, rt.path, p.Name)
+		}
+		query.Set(p.Name, v)
+	}
+
+	path := strings.Join(parts, "/")
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+// checkRoute serves a GET request of rt and checks the response against
+// the spec.
+func checkRoute(t *testing.T, app *application, h http.Handler, rt route, path string) int {
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if err := app.openapi.Check(rt.method, rt.path, rec.Code, rec.Body.Bytes()); err != nil {
+		t.Errorf("GET %s: %v", path, err)
+	}
+	return rec.Code
+}
+
+// TestContractErrors checks the error responses of the routes taking path
+// parameters, which are rejected before any query is made.
+func TestContractErrors(t *testing.T) {
+
+	app, h := newTestApplication(nil)
+
+	for _, rt := range app.routeTable() {
+		if rt.method != http.MethodGet || !strings.Contains(rt.path, ":") {
+			continue
+		}
+		t.Run(rt.path, func(t *testing.T) {
+			parts := strings.Split(rt.path, "/")
+			for i, part := range parts {
+				if strings.HasPrefix(part, ":") {
+					parts[i] = "invalid"
+				}
+			}
+			path := strings.Join(parts, "/")
+			code := checkRoute(t, app, h, rt, path)
+			if code != http.StatusBadRequest && code != http.StatusUnauthorized {
+				t.Errorf("GET %s: status %d, want %d or %d", path, code, http.StatusBadRequest, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+// TestContractLegacyStatistics pins the keys of the deprecated statistics
+// route to those its clients were built against.
+func TestContractLegacyStatistics(t *testing.T) {
+
+	app, _ := newTestApplication(nil)
+
+	body, err := json.Marshal(legacyPlayerStats([]*players.PlayersJoinOnPlayerStatsRow{{PlayerID: 7, Team: 160}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.openapi.Check(http.MethodGet, "/statistics/", http.StatusOK, body); err != nil {
+		t.Error(err)
+	}
+
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PlayerID", "Team", "Season", "FirstName", "LastName", "BirthPlace", "BirthCountry", "BirthDate",
+		"Fixture", "Minutes", "Position", "Rating", "Captain", "Substitute", "ShotsTotal", "ShotsOn", "GoalsScored",
+		"GoalsAssisted", "PassesTotal", "PassesKey", "Accuracy", "Tackles", "Block", "Interceptions", "DuelsTotal",
+		"DuelsWon", "DribblesTotal", "DribblesWon", "Yellow", "Red", "PenaltyWon", "PenaltyCommitted", "PenaltyScored",
+		"PenaltyMissed", "PenaltySaved", "Saves"}
+	if len(rows) != 1 || len(rows[0]) != len(want) {
+		t.Fatalf("legacy statistics = %s, want the keys %v", body, want)
+	}
+	for _, k := range want {
+		if _, ok := rows[0][k]; !ok {
+			t.Errorf("legacy statistics have no key %s: %s", k, body)
+		}
+	}
+}
+
+// TestContractLegacyFixtures pins the deprecated fixtures route to a
+// venue of 0 for fixtures without one, as its clients were built against.
+func TestContractLegacyFixtures(t *testing.T) {
+
+	app, _ := newTestApplication(nil)
+
+	f := legacyFixture(&fixture.FixtureRow{ID: 1, League: 39, Season: 2023, Round: 1})
+	body, err := json.Marshal([]legacyFixtureResp{{Fixture: f}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.openapi.Check(http.MethodGet, "/fixtures/", http.StatusOK, body); err != nil {
+		t.Error(err)
+	}
+
+	var rows []struct {
+		Fixture map[string]json.RawMessage `json:"fixture"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || string(rows[0].Fixture["venue"]) != "0" {
+		t.Errorf("legacy fixtures = %s, want a venue of 0", body)
+	}
+	if _, ok := rows[0].Fixture["referee_id"]; ok {
+		t.Errorf("legacy fixtures have a referee_id: %s", body)
+	}
+}
+
+// sample returns the first column of the first row of query, 0 if there
+// is none.
+func sample(t *testing.T, pool *pgxpool.Pool, query string, args ...interface{}) string {
+
+	var v int
+	err := pool.QueryRow(context.Background(), query, args...).Scan(&v)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return strconv.Itoa(v)
+}
+
+// TestContract serves every GET route with ids of the database at
+// PREFOOT_TEST_DSN and checks the responses against the spec.
+func TestContract(t *testing.T) {
+
+	dsn := os.Getenv("PREFOOT_TEST_DSN")
+	if dsn == "" {
+		t.Skip("PREFOOT_TEST_DSN is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// the latest played fixture and its teams
+	var fixture, league, season, round, home, away, timestamp int
+	err = pool.QueryRow(context.Background(),
+		`SELECT id, league, season, round, home_team, away_team, timestamp FROM fixtures
+			WHERE home_goals IS NOT NULL ORDER BY timestamp DESC LIMIT 1`).
+		Scan(&fixture, &league, &season, &round, &home, &away, &timestamp)
+	if err != nil {
+		t.Fatalf("the database needs a played fixture: %v", err)
+	}
+	itoa := strconv.Itoa
+	values := map[string]string{
+		"fixtures": itoa(fixture),
+		"league":   itoa(league),
+		"season":   itoa(season),
+		"round":    itoa(round),
+		"ts":       itoa(timestamp),
+		"teams":    itoa(home),
+		"team":     itoa(home),
+		"teamId":   itoa(home),
+		"team1":    itoa(home),
+		"homeTeam": itoa(home),
+		"opponent": itoa(away),
+		"team2":    itoa(away),
+		"awayTeam": itoa(away),
+		"players":  sample(t, pool, `SELECT player FROM player_statistics WHERE fixture = $1 ORDER BY minutes DESC LIMIT 1`, fixture),
+		"coaches":  sample(t, pool, `SELECT coach FROM coach_careers WHERE team = $1 ORDER BY start DESC LIMIT 1`, home),
+		"referees": sample(t, pool, `SELECT referee_id FROM fixtures WHERE id = $1 AND referee_id IS NOT NULL`, fixture),
+		"venues":   sample(t, pool, `SELECT venue FROM fixtures WHERE id = $1 AND venue IS NOT NULL`, fixture),
+		"token":    "invalid",
+	}
+
+	app, h := newTestApplication(pool)
+
+	for _, rt := range app.routeTable() {
+		if rt.method != http.MethodGet {
+			continue
+		}
+		t.Run(rt.path, func(t *testing.T) {
+			path := requestPath(t, app, rt, values)
+			if code := checkRoute(t, app, h, rt, path); code >= http.StatusInternalServerError {
+				t.Errorf("GET %s: status %d", path, code)
+			}
+		})
+	}
+}
@@ -5,47 +5,96 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/bernhardson/prefoot/internal/binding"
 	"github.com/bernhardson/prefoot/internal/models"
 	"github.com/bernhardson/prefoot/internal/validator"
+	"github.com/bernhardson/prefoot/pkg/availability"
 	"github.com/bernhardson/prefoot/pkg/fixture"
+	"github.com/bernhardson/prefoot/pkg/players"
 	"github.com/bernhardson/prefoot/pkg/result"
 	"github.com/bernhardson/prefoot/pkg/team"
 	"github.com/julienschmidt/httprouter"
 )
 
+type leagueSeasonParams struct {
+	League int `path:"league" min:"1"`
+	Season int `path:"season" min:"1900" max:"2100"`
+}
+
+type roundsParams struct {
+	leagueSeasonParams
+	TS int64 `query:"ts" required:"true" min:"0"`
+}
+
 // get last round by timestamp
 // used in the round list screen to get the initially displayed round
 func (app *application) getRounds(w http.ResponseWriter, r *http.Request) {
 
-	league, err := strconv.Atoi(r.URL.Query().Get("league"))
-	if err != nil {
-		app.serverError(w, err)
+	var p roundsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	season, err := strconv.Atoi(r.URL.Query().Get("season"))
+	res, err := app.fixture.RoundRepo.SelectRoundByTimestamp(p.League, p.Season, p.TS)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	ts, err := strconv.ParseInt(r.URL.Query().Get("ts"), 10, 0)
-	var res interface{}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+type leaguesParams struct {
+	Country string `query:"country"`
+	Type    string `query:"type" enum:"League,Cup"`
+	pageParams
+}
+
+func (app *application) getLeagues(w http.ResponseWriter, r *http.Request) {
 
+	var p leaguesParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.league.Repo.SelectLeagues(p.Country, p.Type, p.request())
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	res, err = app.fixture.RoundRepo.SelectRoundByTimestamp(league, season, ts)
+	app.writePage(w, r, res, "items", res.Next, res.Total, p.pageParams)
+}
+
+type leagueParams struct {
+	League int `path:"league" min:"1"`
+}
+
+func (app *application) getSeasons(w http.ResponseWriter, r *http.Request) {
 
+	var p leagueParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	// unknown leagues are a 404 rather than an empty list
+	if _, err := app.league.Repo.Select(p.League); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+	res, err := app.league.Repo.SelectSeasons(p.League)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
@@ -54,39 +103,105 @@ func (app *application) getRounds(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
-// unused right now
-func (app *application) getPlayers(w http.ResponseWriter, r *http.Request) {
+type venuesParams struct {
+	City string `query:"city"`
+	pageParams
+}
+
+func (app *application) getVenues(w http.ResponseWriter, r *http.Request) {
 
-	teamId, err := strconv.Atoi(r.URL.Query().Get("teamId"))
+	var p venuesParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
 
+	res, err := app.team.VenuesRepo.SelectVenues(p.City, p.request())
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
-	res, err := app.player.Repo.SelectPlayersByTeamId(teamId)
 
+	app.writePage(w, r, res, "items", res.Next, res.Total, p.pageParams)
+}
+
+func (app *application) getVenue(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.team.VenuesRepo.Select(p.ID)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
+}
 
+// venue statistics of one season or, without season, of all seasons
+type venueStatsParams struct {
+	idParams
+	Season int `query:"season" min:"1900" max:"2100"`
 }
 
-// unused right now
-func (app *application) getStatistics(w http.ResponseWriter, r *http.Request) {
-	teamId, err := strconv.Atoi(r.URL.Query().Get("teamId"))
+func (app *application) getVenueStats(w http.ResponseWriter, r *http.Request) {
+
+	var p venueStatsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.team.VenuesRepo.SelectStats(p.ID, p.Season)
 	if err != nil {
-		app.clientError(w, http.StatusNotFound)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	res, err := app.player.Repo.SelectPlayersAndStatisticsByTeamId(teamId)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+type refereesParams struct {
+	Country string `query:"country"`
+	pageParams
+}
+
+func (app *application) getReferees(w http.ResponseWriter, r *http.Request) {
+
+	var p refereesParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.referees.SelectReferees(p.Country, p.request())
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writePage(w, r, res, "items", res.Next, res.Total, p.pageParams)
+}
+
+func (app *application) getReferee(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.referees.Select(p.ID)
+	if err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
@@ -95,163 +210,505 @@ func (app *application) getStatistics(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
-type standingResponse struct {
-	Standings *[]*result.ResultRow  `json:"standings"`
-	Teams     map[int]*team.TeamRow `json:"teams"`
+// referee statistics of one season or, without season, of all seasons
+type refereeStatsParams struct {
+	idParams
+	Season int `query:"season" min:"1900" max:"2100"`
 }
 
-func (app *application) getLeagueStanding(w http.ResponseWriter, r *http.Request) {
+func (app *application) getRefereeStats(w http.ResponseWriter, r *http.Request) {
+
+	var p refereeStatsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	if _, err := app.referees.Select(p.ID); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
 
-	league, err := strconv.Atoi(r.URL.Query().Get("league"))
+	res, err := app.referees.SelectStats(p.ID, p.Season)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	season, err := strconv.Atoi(r.URL.Query().Get("season"))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (app *application) getCoach(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.coach.Repo.Select(p.ID)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// getCoachCareer writes the jobs of a coach with the performance of each.
+func (app *application) getCoachCareer(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	results, err := app.fixture.ResultRepo.SelectByLeagueSeason(league, season)
+	if _, err := app.coach.Repo.Select(p.ID); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+	res, err := app.coach.Career(p.ID)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	teamSeason, err := app.team.TeamRepo.SelectTeamsSeason(league, season)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+type bounceParams struct {
+	idParams
+	N int `query:"n" default:"5" min:"1" max:"38"`
+}
+
+// getCoachBounce compares the first n matches of each job of a coach with
+// the last n matches of the predecessor at the team.
+func (app *application) getCoachBounce(w http.ResponseWriter, r *http.Request) {
+
+	var p bounceParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	if _, err := app.coach.Repo.Select(p.ID); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+	res, err := app.coach.Bounces(p.ID, p.N)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
-	var ids = make([]int, 20)
-	for _, t := range *teamSeason {
-		ids = append(ids, t.Team)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+type teamParams struct {
+	TeamId int `path:"id" min:"1"`
+}
+
+type teamPageParams struct {
+	teamParams
+	pageParams
+}
+
+func (app *application) getTeam(w http.ResponseWriter, r *http.Request) {
+
+	var p teamParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
 	}
 
-	teams, err := app.team.TeamRepo.SelectTeamsByIds(&ids)
+	res, err := app.team.TeamRepo.Select(p.TeamId)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
-	ts := make(map[int]*team.TeamRow)
-	for _, t := range *teams {
-		ts[t.Id] = t
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// getPlayer writes the profile of a player with the team seasons.
+func (app *application) getPlayer(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
 	}
-	resp := &standingResponse{
-		Teams:     ts,
-		Standings: results,
+
+	person, err := app.player.Repo.SelectPerson(p.ID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
 	}
+	memberships, err := app.player.Repo.SelectMemberships(p.ID)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(&players.Profile{PersonRow: *person, Memberships: memberships})
+}
 
+func (app *application) getPlayers(w http.ResponseWriter, r *http.Request) {
+
+	var p teamPageParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.player.Repo.SelectPlayersByTeamId(p.TeamId, p.request())
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writePage(w, r, res, "items", res.Next, res.Total, p.pageParams)
 }
 
-type fixtureResp struct {
-	Fixture *fixture.FixtureRow `json:"fixture"`
-	Home    *team.TeamRow       `json:"home"`
-	Away    *team.TeamRow       `json:"away"`
+func (app *application) getSquad(w http.ResponseWriter, r *http.Request) {
+
+	var p teamParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.player.Repo.SelectSquad(p.TeamId)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
 }
 
-func (app *application) getFixture(w http.ResponseWriter, r *http.Request) {
+func (app *application) getStatistics(w http.ResponseWriter, r *http.Request) {
+
+	var p teamPageParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
 
-	league, err := strconv.Atoi(r.URL.Query().Get("league"))
+	res, err := app.player.Repo.SelectPlayersAndStatisticsByTeamId(p.TeamId, p.request())
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	app.writePage(w, r, res, "items", res.Next, res.Total, p.pageParams)
+}
+
+// team statistics of a league season, last sets the size of the recent
+// and rolling windows, 0 leaves them out
+type teamSeasonStatsParams struct {
+	leagueSeasonParams
+	Team int `path:"team" min:"1"`
+	Last int `query:"last" default:"5" min:"0" max:"50"`
+}
+
+func (app *application) getTeamSeasonStats(w http.ResponseWriter, r *http.Request) {
+
+	var p teamSeasonStatsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	season, err := strconv.Atoi(r.URL.Query().Get("season"))
+	res, err := app.team.SeasonStats(p.Team, p.League, p.Season, p.Last)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	round, err := strconv.Atoi(r.URL.Query().Get("round"))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// leaderboard of a league season, without min_minutes ratings and per 90
+// values require a third of the most minutes played
+type leadersParams struct {
+	leagueSeasonParams
+	Category   string `query:"category" default:"goals" enum:"goals,assists,contributions,key_passes,tackles,saves,yellow,red,cards,rating"`
+	Per90      bool   `query:"per90" default:"false"`
+	MinMinutes int    `query:"min_minutes" min:"0"`
+	Limit      int    `query:"limit" default:"20" min:"1" max:"100"`
+}
+
+func (app *application) getLeaders(w http.ResponseWriter, r *http.Request) {
+
+	p := leadersParams{MinMinutes: -1}
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	check := p.Category == players.CategoryGoals || p.Category == players.CategoryAssists || p.Category == players.CategoryContributions
+	check = check && app.league.Coverage(p.League, p.Season).TopScorers
+
+	res, err := app.player.Leaders(p.League, p.Season, p.Category, p.Per90, p.MinMinutes, p.Limit, check)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	fixtures, err := app.fixture.Repo.SelectFixtureByLeagueSeasonRound(league, season, round)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// metrics of a player in a league season
+type playerMetricsParams struct {
+	idParams
+	League int `query:"league" required:"true" min:"1"`
+	Season int `query:"season" required:"true" min:"1900" max:"2100"`
+}
+
+func (app *application) getPlayerMetrics(w http.ResponseWriter, r *http.Request) {
+
+	var p playerMetricsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.metrics.SelectPlayer(p.ID, p.League, p.Season)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	body := []fixtureResp{}
-	for _, f := range fixtures {
-		ht, err := app.team.TeamRepo.Select(f.HomeTeam)
-		if err != nil {
-			app.serverError(w, err)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
 
-		at, err := app.team.TeamRepo.Select(f.AwayTeam)
-		if err != nil {
-			app.serverError(w, err)
-			return
-		}
-		fixture := fixtureResp{Fixture: f, Home: ht, Away: at}
-		body = append(body, fixture)
+// form of a player in a season, of one league or without league of all
+// competitions
+type playerFormParams struct {
+	idParams
+	League int `query:"league" min:"1"`
+	Season int `query:"season" required:"true" min:"1900" max:"2100"`
+	Window int `query:"window" default:"5" min:"1" max:"38"`
+}
+
+func (app *application) getPlayerForm(w http.ResponseWriter, r *http.Request) {
+
+	var p playerFormParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.player.Form(p.ID, p.League, p.Season, p.Window)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(body)
+	json.NewEncoder(w).Encode(res)
+}
 
+// players of a league season in form over their last window appearances
+type inFormParams struct {
+	leagueSeasonParams
+	Window         int    `query:"window" default:"5" min:"1" max:"38"`
+	MinAppearances int    `query:"min_appearances" default:"3" min:"1" max:"38"`
+	By             string `query:"by" default:"rating" enum:"rating,delta,contributions"`
+	Limit          int    `query:"limit" default:"20" min:"1" max:"100"`
 }
 
-// returns object to show key players of selected match
-func (app *application) getPlayerStats(w http.ResponseWriter, r *http.Request) {
+func (app *application) getInForm(w http.ResponseWriter, r *http.Request) {
 
-	league, err := strconv.Atoi(r.URL.Query().Get("league"))
+	var p inFormParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.player.InForm(p.League, p.Season, p.Window, p.MinAppearances, p.By, p.Limit)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+type teamMetricsParams struct {
+	leagueSeasonParams
+	Team int `path:"team" min:"1"`
+}
+
+func (app *application) getTeamMetrics(w http.ResponseWriter, r *http.Request) {
+
+	var p teamMetricsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := app.metrics.SelectTeam(p.Team, p.League, p.Season)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// standingResponse holds all results of a league season, teams the teams
+// of the results
+type standingResponse struct {
+	Standings []*result.ResultRow   `json:"standings"`
+	Teams     map[int]*team.TeamRow `json:"teams"`
+}
+
+func (app *application) getLeagueStanding(w http.ResponseWriter, r *http.Request) {
+
+	var p leagueSeasonParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	season, err := strconv.Atoi(r.URL.Query().Get("season"))
+	results, err := app.fixture.ResultRepo.SelectByLeagueSeason(p.League, p.Season)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	team, err := strconv.Atoi(r.URL.Query().Get("homeTeam"))
+	var ids []int
+	for _, res := range results {
+		ids = append(ids, res.Team)
+	}
+
+	ts, err := app.teamLoader().LoadMany(ids...)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
+	resp := &standingResponse{
+		Teams:     ts,
+		Standings: results,
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+type fixtureResp struct {
+	Fixture *fixture.FixtureRow `json:"fixture"`
+	Home    *team.TeamRow       `json:"home"`
+	Away    *team.TeamRow       `json:"away"`
+}
+
+type roundParams struct {
+	leagueSeasonParams
+	Round int `path:"round" min:"1" max:"100"`
+}
+
+func (app *application) getFixtureById(w http.ResponseWriter, r *http.Request) {
 
-	awayTeam, err := strconv.Atoi(r.URL.Query().Get("awayTeam"))
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	f, err := app.fixture.Repo.SelectWithTeams(p.ID)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fixtureResp{Fixture: &f.FixtureRow, Home: f.Home, Away: f.Away})
+}
+
+func (app *application) getFixture(w http.ResponseWriter, r *http.Request) {
+
+	var p roundParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	round, err := strconv.Atoi(r.URL.Query().Get("round"))
+	fixtures, err := app.fixture.Repo.SelectWithTeamsByLeagueSeasonRound(p.League, p.Season, p.Round)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	body := []fixtureResp{}
+	for _, f := range fixtures {
+		body = append(body, fixtureResp{Fixture: &f.FixtureRow, Home: f.Home, Away: f.Away})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+
+}
+
+type playerStatsParams struct {
+	roundParams
+	HomeTeam int `query:"homeTeam" required:"true" min:"1"`
+	AwayTeam int `query:"awayTeam" required:"true" min:"1"`
+}
+
+// returns object to show key players of selected match
+func (app *application) getPlayerStats(w http.ResponseWriter, r *http.Request) {
+
+	var p playerStatsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	players, err := app.player.Repo.SelectPlayerIdsBySeasonAndTeamId(season, team)
+	players, err := app.player.Repo.SelectPlayerIdsBySeasonAndTeamId(p.Season, p.HomeTeam)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	aplayers, err := app.player.Repo.SelectPlayerIdsBySeasonAndTeamId(season, awayTeam)
+	aplayers, err := app.player.Repo.SelectPlayerIdsBySeasonAndTeamId(p.Season, p.AwayTeam)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	fixtures, err := app.fixture.Repo.SelectFixtureIdsForLastNRounds(league, season, round, 7)
+	fixtures, err := app.fixture.Repo.SelectFixtureIdsForLastNRounds(p.League, p.Season, p.Round, 7)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
@@ -259,60 +716,119 @@ func (app *application) getPlayerStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := app.player.Repo.SelectPlayerStatisticsByPlayersFixturesTeam(players, fixtures)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
+	absences, err := app.matchAbsences(p.League, p.Season, p.Round, p.HomeTeam, p.AwayTeam)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+	for _, s := range stats {
+		if a, ok := absences[s.PlayerID]; ok && a.Status == availability.Out {
+			s.Unavailable, s.UnavailableReason = true, a.Reason
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(stats)
 
 }
 
-type matchups struct {
-	Fixture []*fixture.FixtureRow `json:"fixture"`
-	Teams   map[int]team.TeamRow  `json:"teams"`
+// matchAbsences returns the absences of the match of home and away in a
+// round by player, none if the round has no such match.
+func (app *application) matchAbsences(league, season, round, home, away int) (map[int]*availability.Absence, error) {
+
+	fixtures, err := app.fixture.Repo.SelectFixtureByLeagueSeasonRound(league, season, round)
+	if err != nil {
+		return nil, err
+	}
+
+	absences := make(map[int]*availability.Absence)
+	for _, f := range fixtures {
+		if f.HomeTeam != home || f.AwayTeam != away {
+			continue
+		}
+		as, err := app.availability.Absences(f.ID, league, season, home, away)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range as {
+			absences[a.Player] = a
+		}
+	}
+	return absences, nil
 }
 
-func (app *application) getLastNMatchups(w http.ResponseWriter, r *http.Request) {
+type availabilityResponse struct {
+	Fixture     int                     `json:"fixture"`
+	Unavailable []*availability.Absence `json:"unavailable"`
+}
 
-	team1, err := strconv.Atoi(r.URL.Query().Get("team1"))
-	if err != nil {
-		app.serverError(w, err)
+// getAvailability lists the players expected to miss or doubtful for a
+// fixture.
+func (app *application) getAvailability(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
-	team2, err := strconv.Atoi(r.URL.Query().Get("team2"))
+
+	f, err := app.fixture.Repo.Select(p.ID)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	absences, err := app.availability.Absences(f.ID, f.League, f.Season, f.HomeTeam, f.AwayTeam)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	team1Row, err := app.team.TeamRepo.Select(team1)
-	if err != nil {
-		app.serverError(w, err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&availabilityResponse{Fixture: f.ID, Unavailable: absences})
+}
+
+type matchups struct {
+	Fixture []*fixture.FixtureRow `json:"fixture"`
+	Teams   map[int]team.TeamRow  `json:"teams"`
+}
+
+type matchupsParams struct {
+	Team1 int `path:"id" min:"1"`
+	Team2 int `path:"opponent" min:"1"`
+	N     int `query:"n" default:"5" min:"1" max:"50"`
+}
+
+func (app *application) getLastNMatchups(w http.ResponseWriter, r *http.Request) {
+
+	var p matchupsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
-	team2Row, err := app.team.TeamRepo.Select(team2)
+
+	team1, team2 := p.Team1, p.Team2
+	teams, err := app.teamLoader().LoadMany(team1, team2)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	fixtures, err := app.fixture.Repo.SelectLastNMatchups(team1, team2, n)
+	fixtures, err := app.fixture.Repo.SelectLastNMatchups(team1, team2, p.N)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
 	resp := &matchups{
 		Fixture: fixtures,
-		Teams:   map[int]team.TeamRow{team1: *team1Row, team2: *team2Row},
+		Teams:   map[int]team.TeamRow{team1: *teams[team1], team2: *teams[team2]},
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -325,39 +841,32 @@ type lastNFixture struct {
 	Result  *result.ResultRow
 }
 
+type lastNFixturesParams struct {
+	Team int `path:"id" min:"1"`
+	N    int `query:"n" default:"5" min:"1" max:"20"`
+}
+
 func (app *application) getLastNFixturesByTeam(w http.ResponseWriter, r *http.Request) {
 
-	team1, err := strconv.Atoi(r.URL.Query().Get("team"))
-	if err != nil {
-		app.serverError(w, err)
-		return
-	}
-	n, err := strconv.Atoi(r.URL.Query().Get("n"))
-	if err != nil {
-		app.serverError(w, err)
+	var p lastNFixturesParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
+	team1, n := p.Team, p.N
+
 	ts := int(time.Now().Unix())
 
-	fixtures, err := app.fixture.Repo.SelectLastNFixturesByTeam(team1, ts, n)
+	fixtures, err := app.fixture.Repo.SelectLastNFixturesWithResultsByTeam(team1, ts, n)
 	if err != nil {
-		app.serverError(w, err)
+		app.errorResponse(w, r, err)
 		return
 	}
 
 	var resp []*lastNFixture
 	for _, f := range fixtures {
-		app.logger.Debug().Msg(fmt.Sprintf("query results for league %d, season %d, team %d, round %d", f.League, f.Season, team1, f.Round))
-		result, err := app.fixture.ResultRepo.SelectResultByLeagueSeasonTeamRound(f.League, f.Season, team1, f.Round)
-		if err != nil {
-			if strings.HasPrefix(err.Error(), "no rows in result set") {
-				continue
-			}
-			app.serverError(w, err)
-			return
-		}
-		resp = append(resp, &lastNFixture{Fixture: f, Result: result})
+		resp = append(resp, &lastNFixture{Fixture: &f.FixtureRow, Result: f.Result})
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -365,61 +874,66 @@ func (app *application) getLastNFixturesByTeam(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(resp)
 }
 
-func (app *application) initDB(w http.ResponseWriter, r *http.Request) {
-
-	var params struct {
+type initParams struct {
+	Body struct {
 		Leagues []int `json:"leagues"`
-	}
+	} `body:"json"`
+}
 
-	err := json.NewDecoder(r.Body).Decode(&params)
-	if err != nil {
-		app.logger.Err(err).Msg("failed to decode JSON payload")
-		http.Error(w, "failed to decode JSON payload", http.StatusBadRequest)
+func (app *application) initDB(w http.ResponseWriter, r *http.Request) {
+
+	var p initParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
 
-	//leagues := []int{71, 137}
-
-	for _, l := range params.Leagues {
-		lresp, fs, err := app.league.FetchAndInsertLeague(l)
-		app.logger.Err(err).Msg(fmt.Sprintf("insert leagues: failed=%v", *fs))
-
-		for _, s := range lresp.Response[0].Seasons {
-			year := s.Year
-
-			app.logger.Info().Msg(fmt.Sprintf("inserting league=%d#season=%d", l, year))
-			ts, err := app.team.FetchAndInsertTeams(l, year)
-			app.logger.Err(err).Msg(fmt.Sprintf("insert teams: league=%d#season=%d", l, year))
+	app.ingestLeagues(p.Body.Leagues)
+}
 
-			fp, fs, err := app.player.FetchAndInsertPlayers(l, year)
-			app.logger.Err(err).Msg(fmt.Sprintf("insert players: league:%d#season=%d", l, year))
-			app.logger.Err(err).Msg(fmt.Sprintf("insert players: failedP=%v # failedS=%v", *fp, *fs))
+func (app *application) syncSquadsPost(w http.ResponseWriter, r *http.Request) {
 
-			err = app.fixture.FetchAndInsertFixtures(l, year)
-			app.logger.Err(err).Msg(fmt.Sprintf("insert fixtures: league:%d#season=%d", l, year))
+	var p leagueSeasonParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
 
-			fc, fcc, err := app.coach.FetchAndInsertCoaches(ts)
-			app.logger.Err(err).Msg(fmt.Sprintf("insert coaches: league:%d#season=%d#failedC=%v#failedCC=%v", l, year, *fc, *fcc))
-		}
+	if _, err := app.syncSquads(p.League, p.Season); err != nil {
+		app.serverError(w, r, err)
+		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (app *application) updateDb(w http.ResponseWriter, r *http.Request) {
 
-	params := httprouter.ParamsFromContext(r.Context())
-
-	league, err := strconv.Atoi(params.ByName("league"))
-	if err != nil {
-		app.serverError(w, err)
+	var p leagueSeasonParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
 		return
 	}
-	season, err := strconv.Atoi(params.ByName("season"))
-	if err != nil {
-		app.serverError(w, err)
+
+	if err := app.fixture.UpdateFixture(p.League, p.Season); err != nil {
+		app.serverError(w, r, err)
 		return
 	}
+	// the fixtures are up to date at this point, so failing extras are only
+	// logged
+	coverage := app.league.Coverage(p.League, p.Season)
+	if coverage.Injuries {
+		if _, err := app.availability.FetchAndInsertInjuries(p.League, p.Season); err != nil {
+			app.logger.Err(err).Msg(fmt.Sprintf("update injuries: league=%d#season=%d", p.League, p.Season))
+		}
+	}
+	if coverage.TopScorers {
+		if _, err := app.player.FetchAndInsertTopScorers(p.League, p.Season); err != nil {
+			app.logger.Err(err).Msg(fmt.Sprintf("update top scorers: league=%d#season=%d", p.League, p.Season))
+		}
+	}
 
-	app.fixture.UpdateFixture(league, season)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 type userSignupForm struct {
@@ -439,12 +953,12 @@ func (app *application) userSignup(w http.ResponseWriter, r *http.Request) {
 
 	password := params.ByName("password")
 
-	err := app.users.Insert(name, email, password)
+	_, err := app.users.Insert(name, email, password)
 	if err != nil {
 		if errors.Is(err, models.ErrDuplicateEmail) {
 			//return error
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
@@ -460,41 +974,50 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
 	var user user
 	err = json.Unmarshal(body, &user)
 	if err != nil {
-		app.serverError(w, err)
+		app.badRequest(w, r, "failed to decode JSON payload")
+		return
 	}
 
-	valid := validator.NotBlank(user.Name)
-	valid = valid && validator.NotBlank(user.Email)
-	valid = valid && validator.Matches(user.Email, validator.EmailRX)
-	valid = valid && validator.NotBlank(user.Password)
-	valid = valid && validator.MinChars(user.Password, 8)
+	var v validator.Validator
+	v.CheckField(validator.NotBlank(user.Name), "name", "This field cannot be blank")
+	v.CheckField(validator.NotBlank(user.Email), "email", "This field cannot be blank")
+	v.CheckField(validator.Matches(user.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	v.CheckField(validator.NotBlank(user.Password), "password", "This field cannot be blank")
+	v.CheckField(validator.MinChars(user.Password, 8), "password", "This field must be at least 8 characters long")
 
 	// If there are any errors, redisplay the signup form along with a 422 // status code.
 
-	if !valid {
-		app.serverError(w, errors.New("user email and/or password are invalid"))
+	if !v.Valid() {
+		app.failedValidation(w, r, v)
 		return
 	}
 
 	// Try to create a new user record in the database. If the email already
 	// exists then add an error message to the form and re-display it.
-	err = app.users.Insert(user.Name, user.Email, user.Password)
+	id, err := app.users.Insert(user.Name, user.Email, user.Password)
 	if err != nil {
 		if errors.Is(err, models.ErrDuplicateEmail) {
-			//todo return proper error message
+			v.AddFieldError("email", "Email address is already in use")
+			app.failedValidation(w, r, v)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	// a failing mail server must not fail the signup, the link can be resent
+	err = app.sendVerificationMail(id, user.Email)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("user_%d: failed to send verification mail", id))
+	}
+
 	// Otherwise add a confirmation flash message to the session confirming that
 	// their signup worked.
 	app.sessionManager.Put(r.Context(), "flash", "Your signup was successful. Please log in.")
@@ -506,29 +1029,59 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 
 	var user user
+	err := json.NewDecoder(r.Body).Decode(&user)
+	if err != nil {
+		app.badRequest(w, r, "failed to decode JSON payload")
+		return
+	}
+
+	var v validator.Validator
+	v.CheckField(validator.NotBlank(user.Email), "email", "This field cannot be blank")
+	v.CheckField(validator.Matches(user.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	v.CheckField(validator.NotBlank(user.Password), "password", "This field cannot be blank")
 
-	valid := validator.NotBlank(user.Email)
-	valid = valid && validator.Matches(user.Email, validator.EmailRX)
-	valid = valid && validator.NotBlank(user.Password)
+	if !v.Valid() {
+		app.failedValidation(w, r, v)
+		return
+	}
 
-	if !valid {
-		app.serverError(w, errors.New("user email or password wrong"))
+	// throttle before bcrypt so attempts can't be used to burn cpu, the
+	// attempt counts as failed until the password is known to be right
+	ipKey, accountKey := loginThrottleKeys(r, user.Email)
+	wait, err := app.reserveLogin(ipKey, accountKey)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		app.clientError(w, r, http.StatusTooManyRequests)
 		return
 	}
 
 	id, err := app.users.Authenticate(user.Email, user.Password)
 	if err != nil {
 		if errors.Is(err, models.ErrInvalidCredentials) {
-			app.serverError(w, models.ErrInvalidCredentials)
+			app.writeProblem(w, r, &problem{Status: http.StatusUnauthorized, Detail: "Email or password is wrong."})
 		} else {
-			app.serverError(w, err)
+			app.releaseLogin(ipKey, accountKey)
+			app.serverError(w, r, err)
 		}
 		return
 	}
 
+	err = app.loginThrottle.Reset(accountKey)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("failed to reset login throttle %s", accountKey))
+	}
+	err = app.loginThrottle.Release(models.IPThrottle, ipKey)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("failed to release login attempt %s", ipKey))
+	}
+
 	err = app.sessionManager.RenewToken(r.Context())
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -547,7 +1100,7 @@ func (app *application) userLogoutPost(w http.ResponseWriter, r *http.Request) {
 
 	err := app.sessionManager.RenewToken(r.Context())
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 	app.sessionManager.Remove(r.Context(), "authenticatedUserID")
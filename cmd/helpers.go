@@ -1,37 +1,141 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/bernhardson/prefoot/internal/binding"
+	"github.com/bernhardson/prefoot/internal/loader"
+	"github.com/bernhardson/prefoot/internal/models"
+	"github.com/bernhardson/prefoot/internal/validator"
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/jackc/pgx/v5"
 )
 
+// problem is an RFC 7807 problem details object. All error responses of the
+// api are sent as application/problem+json.
+type problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+func (app *application) writeProblem(w http.ResponseWriter, r *http.Request, p *problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// errorResponse maps err to the matching problem response. Malformed
+// parameters become 400, invalid ones 422, missing rows 404 and everything
+// else 500.
+func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, err error) {
+
+	var be *binding.Error
+	var pe *shared.PageError
+	switch {
+	case errors.As(err, &be):
+		status := http.StatusUnprocessableEntity
+		if be.Malformed {
+			status = http.StatusBadRequest
+		}
+		p := &problem{Status: status, Errors: be.FieldErrors}
+		if len(be.NonFieldErrors) > 0 {
+			p.Detail = be.NonFieldErrors[0]
+		}
+		app.writeProblem(w, r, p)
+	case errors.As(err, &pe):
+		app.writeProblem(w, r, &problem{
+			Status: http.StatusUnprocessableEntity,
+			Errors: map[string]string{pe.Param: pe.Message},
+		})
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, models.ErrNoRecord), errors.Is(err, loader.ErrNotFound):
+		app.notFound(w, r)
+	default:
+		app.serverError(w, r, err)
+	}
+}
+
 // The serverError helper writes an error message and stack trace to the errorLog,
-// then sends a generic 500 Internal Server Error response to the user.
-func (app *application) serverError(w http.ResponseWriter, err error) {
+// then sends a generic 500 Internal Server Error response to the user. Details
+// of err are never sent to the client.
+func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
 
-	app.logger.Err(err).Msg(fmt.Sprintf("%s\n%s", err.Error(), debug.Stack()))
+	app.logger.Err(err).Msg(fmt.Sprintf("%s %s: %s\n%s", r.Method, r.URL.RequestURI(), err.Error(), debug.Stack()))
 
-	http.Error(w, err.Error(), http.StatusInternalServerError)
+	app.writeProblem(w, r, &problem{
+		Status: http.StatusInternalServerError,
+		Detail: "The server encountered a problem and could not process your request.",
+	})
 }
 
 // The clientError helper sends a specific status code and corresponding description
-// to the user. We'll use this later in the book to send responses like 400 "Bad
-// Request" when there's a problem with the request that the user sent.
-func (app *application) clientError(w http.ResponseWriter, status int) {
-	http.Error(w, http.StatusText(status), status)
+// to the user, e.g. 400 "Bad Request" when there's a problem with the request
+// that the user sent.
+func (app *application) clientError(w http.ResponseWriter, r *http.Request, status int) {
+	app.writeProblem(w, r, &problem{Status: status})
+}
+
+func (app *application) badRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	app.writeProblem(w, r, &problem{Status: http.StatusBadRequest, Detail: detail})
 }
 
 // For consistency, we'll also implement a notFound helper. This is simply a
 // convenience wrapper around clientError which sends a 404 Not Found response to
 // the user.
-func (app *application) notFound(w http.ResponseWriter) {
-	app.clientError(w, http.StatusNotFound)
+func (app *application) notFound(w http.ResponseWriter, r *http.Request) {
+	app.clientError(w, r, http.StatusNotFound)
+}
+
+// failedValidation sends the field errors of v with a 422 status.
+func (app *application) failedValidation(w http.ResponseWriter, r *http.Request, v validator.Validator) {
+
+	p := &problem{
+		Status: http.StatusUnprocessableEntity,
+		Errors: v.FieldErrors,
+	}
+	if len(v.NonFieldErrors) > 0 {
+		p.Detail = v.NonFieldErrors[0]
+	}
+	app.writeProblem(w, r, p)
 }
 
 func (app *application) isAuthenticated(r *http.Request) bool {
-	return app.sessionManager.Exists(r.Context(), "authenticatedUserID")
+	isAuthenticated, ok := r.Context().Value(isAuthenticatedContextKey).(bool)
+	if !ok {
+		return false
+	}
+	return isAuthenticated
+}
+
+// authenticatedUserID returns the id of the user set by the authenticate
+// middleware, either from the session or from an API token.
+func (app *application) authenticatedUserID(r *http.Request) int {
+	id, ok := r.Context().Value(authenticatedUserIDContextKey).(int)
+	if !ok {
+		return 0
+	}
+	return id
+}
+
+func (app *application) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
 }
 
 type RapidResponse struct {
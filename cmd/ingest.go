@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ingestLeagues downloads and inserts leagues with all their seasons:
+// teams, players, fixtures and coaches.
+func (app *application) ingestLeagues(leagues []int) {
+
+	for _, l := range leagues {
+		lresp, fs, err := app.league.FetchAndInsertLeague(l)
+		if err != nil || len(lresp.Response) == 0 {
+			app.logger.Err(err).Msg(fmt.Sprintf("insert league: league=%d", l))
+			continue
+		}
+		app.logger.Info().Msg(fmt.Sprintf("insert leagues: failed=%v", *fs))
+
+		for _, s := range lresp.Response[0].Seasons {
+			year := s.Year
+
+			app.logger.Info().Msg(fmt.Sprintf("inserting league=%d#season=%d", l, year))
+			ts, err := app.team.FetchAndInsertTeams(l, year)
+			if err != nil {
+				app.logger.Err(err).Msg(fmt.Sprintf("insert teams: league=%d#season=%d", l, year))
+				continue
+			}
+
+			// players missing from the players endpoint are still added
+			// from fixture statistics
+			if s.Coverage.Players {
+				fp, fs, err := app.player.FetchAndInsertPlayers(l, year)
+				if err != nil {
+					app.logger.Err(err).Msg(fmt.Sprintf("insert players: league:%d#season=%d", l, year))
+				} else {
+					app.logger.Info().Msg(fmt.Sprintf("insert players: failedP=%v # failedS=%v", *fp, *fs))
+				}
+			} else {
+				app.logger.Info().Msg(fmt.Sprintf("insert players: league:%d#season=%d#not covered", l, year))
+			}
+
+			// squads only exist for now, not for past seasons
+			if s.Current {
+				app.syncSquads(l, year)
+			}
+
+			err = app.fixture.FetchAndInsertFixtures(l, year)
+			app.logger.Err(err).Msg(fmt.Sprintf("insert fixtures: league:%d#season=%d", l, year))
+
+			if s.Coverage.Injuries {
+				if _, err := app.availability.FetchAndInsertInjuries(l, year); err != nil {
+					app.logger.Err(err).Msg(fmt.Sprintf("insert injuries: league:%d#season=%d", l, year))
+				}
+			}
+
+			if s.Coverage.TopScorers {
+				if _, err := app.player.FetchAndInsertTopScorers(l, year); err != nil {
+					app.logger.Err(err).Msg(fmt.Sprintf("insert top scorers: league:%d#season=%d", l, year))
+				}
+			}
+
+			fc, fcc, err := app.coach.FetchAndInsertCoaches(ts)
+			if err != nil {
+				app.logger.Err(err).Msg(fmt.Sprintf("insert coaches: league:%d#season=%d", l, year))
+			} else {
+				app.logger.Info().Msg(fmt.Sprintf("insert coaches: league:%d#season=%d#failedC=%v#failedCC=%v", l, year, *fc, *fcc))
+			}
+		}
+	}
+}
+
+// syncSquads syncs the squads of all teams of a league season. Teams
+// failing to sync are logged and skipped, it returns the number of teams
+// synced.
+func (app *application) syncSquads(league, season int) (int, error) {
+
+	ts, err := app.team.TeamRepo.SelectTeamsSeason(league, season)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("sync squads: league=%d#season=%d", league, season))
+		return 0, err
+	}
+
+	synced := 0
+	for _, t := range *ts {
+		if _, err := app.player.SyncSquad(t.Team, season); err != nil {
+			app.logger.Err(err).Msg(fmt.Sprintf("sync squad: league=%d#season=%d#team=%d", league, season, t.Team))
+			continue
+		}
+		synced++
+	}
+	app.logger.Info().Msg(fmt.Sprintf("sync squads: league=%d#season=%d#teams=%d#synced=%d", league, season, len(*ts), synced))
+	return synced, nil
+}
+
+// reprocess rebuilds the normalised tables of leagues from the response
+// archive. The ingestion runs unchanged, the application's client serves
+// every request from raw_responses and no request reaches the api. The
+// tables are expected to be empty, e.g. freshly created from prefoot.sql.
+func (app *application) reprocess(leagues []int) {
+
+	app.logger.Info().Msg(fmt.Sprintf("reprocess: leagues=%v", leagues))
+	app.ingestLeagues(leagues)
+}
+
+// parseIDs parses a comma separated list of ids.
+func parseIDs(s string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bernhardson/prefoot/internal/binding"
+	"github.com/bernhardson/prefoot/pkg/fixture"
+	"github.com/bernhardson/prefoot/pkg/players"
+	"github.com/bernhardson/prefoot/pkg/result"
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/bernhardson/prefoot/pkg/team"
+	"github.com/julienschmidt/httprouter"
+)
+
+// apiPrefix is the namespace of the versioned api. Routes outside of it are
+// kept as deprecated aliases for clients built against the old query string
+// api.
+const apiPrefix = "/api/v1"
+
+// legacyAlias serves a versioned route under its old path. The old routes
+// passed identifiers as query parameters, query maps the path parameter
+// names of the successor to those query names so the handler can bind its
+// usual path parameters. Responses carry Deprecation and Link headers
+// pointing at the successor.
+func (app *application) legacyAlias(successor string, query map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			values := r.URL.Query()
+			params := httprouter.ParamsFromContext(r.Context())
+			for name, q := range query {
+				params = append(params, httprouter.Param{Key: name, Value: values.Get(q)})
+			}
+
+			w.Header().Set("Deprecation", "true")
+			w.Header().Add("Link", "<"+successorURL(successor, params)+`>; rel="successor-version"`)
+
+			ctx := context.WithValue(r.Context(), httprouter.ParamsKey, params)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// successorURL fills the path parameters of the successor route. Parameters
+// that are missing from the request are left as templates.
+func successorURL(path string, params httprouter.Params) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, ":") {
+			continue
+		}
+		if v := params.ByName(part[1:]); v != "" {
+			parts[i] = url.PathEscape(v)
+		} else {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// legacyShape serves an alias with handler instead of the successor's,
+// for successors whose response shape changed.
+func legacyShape(rt route, handler http.HandlerFunc, params, response interface{}) route {
+	rt.handler, rt.params, rt.response = handler, params, response
+	return rt
+}
+
+// allPages collects the items of all pages of a list, the legacy routes
+// returned whole lists.
+func allPages[T any](list func(shared.PageRequest) (*shared.Page[T], error)) ([]*T, error) {
+
+	items := []*T{}
+	page := shared.PageRequest{Limit: 200}
+	for {
+		p, err := list(page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, p.Items...)
+		if p.Next == "" {
+			return items, nil
+		}
+		page.Cursor = p.Next
+	}
+}
+
+// getPlayersLegacy writes the players of a team as a bare array.
+func (app *application) getPlayersLegacy(w http.ResponseWriter, r *http.Request) {
+
+	var p teamParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := allPages(func(page shared.PageRequest) (*shared.Page[players.PlayerRow], error) {
+		return app.player.Repo.SelectPlayersByTeamId(p.TeamId, page)
+	})
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// legacyPlayerStatsRow is a player with match statistics as the legacy
+// route encoded it, keyed by the field names.
+type legacyPlayerStatsRow struct {
+	PlayerID         int
+	Team             int
+	Season           int
+	FirstName        string
+	LastName         string
+	BirthPlace       string
+	BirthCountry     string
+	BirthDate        string
+	Fixture          int
+	Minutes          int
+	Position         string
+	Rating           float64
+	Captain          bool
+	Substitute       bool
+	ShotsTotal       int
+	ShotsOn          int
+	GoalsScored      int
+	GoalsAssisted    int
+	PassesTotal      int
+	PassesKey        int
+	Accuracy         int
+	Tackles          int
+	Block            int
+	Interceptions    int
+	DuelsTotal       int
+	DuelsWon         int
+	DribblesTotal    int
+	DribblesWon      int
+	Yellow           int
+	Red              int
+	PenaltyWon       int
+	PenaltyCommitted int
+	PenaltyScored    int
+	PenaltyMissed    int
+	PenaltySaved     int
+	Saves            int
+}
+
+// legacyPlayerStats converts rows to the legacy shape.
+func legacyPlayerStats(rows []*players.PlayersJoinOnPlayerStatsRow) []*legacyPlayerStatsRow {
+	res := make([]*legacyPlayerStatsRow, 0, len(rows))
+	for _, r := range rows {
+		l := legacyPlayerStatsRow(*r)
+		res = append(res, &l)
+	}
+	return res
+}
+
+// getStatisticsLegacy writes the match statistics of the players of a
+// team as a bare array.
+func (app *application) getStatisticsLegacy(w http.ResponseWriter, r *http.Request) {
+
+	var p teamParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	res, err := allPages(func(page shared.PageRequest) (*shared.Page[players.PlayersJoinOnPlayerStatsRow], error) {
+		return app.player.Repo.SelectPlayersAndStatisticsByTeamId(p.TeamId, page)
+	})
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(legacyPlayerStats(res))
+}
+
+// legacyFixtureRow is a fixture as the legacy routes encoded it, without
+// the referee id and with 0 for an unknown venue.
+type legacyFixtureRow struct {
+	ID            int    `json:"id"`
+	League        int    `json:"league"`
+	Round         int    `json:"round"`
+	Referee       string `json:"referee"`
+	Timezone      string `json:"timezone"`
+	Timestamp     int    `json:"timestamp"`
+	Venue         int    `json:"venue"`
+	Season        int    `json:"season"`
+	HomeTeam      int    `json:"home_team"`
+	AwayTeam      int    `json:"away_team"`
+	HomeGoals     int    `json:"home_goals"`
+	AwayGoals     int    `json:"away_goals"`
+	HomeGoalsHalf int    `json:"home_goals_half"`
+	AwayGoalsHalf int    `json:"away_goals_half"`
+}
+
+// legacyFixture converts f to the legacy shape.
+func legacyFixture(f *fixture.FixtureRow) *legacyFixtureRow {
+	l := &legacyFixtureRow{
+		ID:            f.ID,
+		League:        f.League,
+		Round:         f.Round,
+		Referee:       f.Referee,
+		Timezone:      f.Timezone,
+		Timestamp:     f.Timestamp,
+		Season:        f.Season,
+		HomeTeam:      f.HomeTeam,
+		AwayTeam:      f.AwayTeam,
+		HomeGoals:     f.HomeGoals,
+		AwayGoals:     f.AwayGoals,
+		HomeGoalsHalf: f.HomeGoalsHalf,
+		AwayGoalsHalf: f.AwayGoalsHalf,
+	}
+	if f.Venue != nil {
+		l.Venue = *f.Venue
+	}
+	return l
+}
+
+type legacyFixtureResp struct {
+	Fixture *legacyFixtureRow `json:"fixture"`
+	Home    *team.TeamRow     `json:"home"`
+	Away    *team.TeamRow     `json:"away"`
+}
+
+// getFixtureLegacy writes the fixtures of a round in the legacy shape.
+func (app *application) getFixtureLegacy(w http.ResponseWriter, r *http.Request) {
+
+	var p roundParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	fixtures, err := app.fixture.Repo.SelectWithTeamsByLeagueSeasonRound(p.League, p.Season, p.Round)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	body := []legacyFixtureResp{}
+	for _, f := range fixtures {
+		body = append(body, legacyFixtureResp{Fixture: legacyFixture(&f.FixtureRow), Home: f.Home, Away: f.Away})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+type legacyLastNFixture struct {
+	Fixture *legacyFixtureRow
+	Result  *result.ResultRow
+}
+
+// getLastNFixturesByTeamLegacy writes the last matches of a team in the
+// legacy shape.
+func (app *application) getLastNFixturesByTeamLegacy(w http.ResponseWriter, r *http.Request) {
+
+	var p lastNFixturesParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	fixtures, err := app.fixture.Repo.SelectLastNFixturesWithResultsByTeam(p.Team, int(time.Now().Unix()), p.N)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	var resp []*legacyLastNFixture
+	for _, f := range fixtures {
+		resp = append(resp, &legacyLastNFixture{Fixture: legacyFixture(&f.FixtureRow), Result: f.Result})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+type legacyMatchups struct {
+	Fixture []*legacyFixtureRow  `json:"fixture"`
+	Teams   map[int]team.TeamRow `json:"teams"`
+}
+
+// getLastNMatchupsLegacy writes the last matches between two teams in the
+// legacy shape.
+func (app *application) getLastNMatchupsLegacy(w http.ResponseWriter, r *http.Request) {
+
+	var p matchupsParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	teams, err := app.teamLoader().LoadMany(p.Team1, p.Team2)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	fixtures, err := app.fixture.Repo.SelectLastNMatchups(p.Team1, p.Team2, p.N)
+	if err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	resp := &legacyMatchups{
+		Fixture: make([]*legacyFixtureRow, 0, len(fixtures)),
+		Teams:   map[int]team.TeamRow{p.Team1: *teams[p.Team1], p.Team2: *teams[p.Team2]},
+	}
+	for _, f := range fixtures {
+		resp.Fixture = append(resp.Fixture, legacyFixture(f))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
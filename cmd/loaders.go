@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/bernhardson/prefoot/internal/loader"
+	"github.com/bernhardson/prefoot/pkg/team"
+)
+
+// teamLoader batches team lookups of one request into a single query.
+func (app *application) teamLoader() *loader.Loader[int, *team.TeamRow] {
+	return loader.New(func(ids []int) (map[int]*team.TeamRow, error) {
+		rows, err := app.team.TeamRepo.SelectTeamsByIds(&ids)
+		if err != nil {
+			return nil, err
+		}
+		teams := make(map[int]*team.TeamRow, len(*rows))
+		for _, t := range *rows {
+			teams[t.Id] = t
+		}
+		return teams, nil
+	})
+}
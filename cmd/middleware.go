@@ -2,9 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bernhardson/prefoot/internal/models"
+	"github.com/bernhardson/prefoot/internal/ratelimit"
 	"github.com/justinas/nosurf"
 )
 
@@ -36,7 +43,7 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 		defer func() { // Use the builtin recover function to check if there has been a // panic or not. If there has...
 			if err := recover(); err != nil { // Set a "Connection: close" header on the response.
 				w.Header().Set("Connection", "close") // Call the app.serverError helper method to return a 500 // Internal Server response.
-				app.serverError(w, fmt.Errorf("%s", err))
+				app.serverError(w, r, fmt.Errorf("%s", err))
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -45,10 +52,11 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 
 func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// If the user is not authenticated, redirect them to the login page and // return from the middleware chain so that no subsequent handlers in // the chain are executed.
+		// If the user is not authenticated, respond with 401 and return from the
+		// middleware chain so that no subsequent handlers in the chain are executed.
 
 		if !app.isAuthenticated(r) {
-			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			app.clientError(w, r, http.StatusUnauthorized)
 			return
 		}
 
@@ -62,6 +70,93 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+// requireVerified only lets users with a confirmed email address through.
+// Not wired up yet, it is meant for the prediction group routes. Must run
+// after requireAuthentication.
+func (app *application) requireVerified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := app.users.Get(app.authenticatedUserID(r))
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		if !user.Verified {
+			app.clientError(w, r, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin only lets admin users through. Requests with an API token
+// additionally need the admin scope. Must run after requireAuthentication.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := app.users.Get(app.authenticatedUserID(r))
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		token, ok := r.Context().Value(tokenContextKey).(*models.Token)
+		if !user.Admin || (ok && !token.HasScope(models.ScopeAdmin)) {
+			app.clientError(w, r, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimits are the buckets api clients are limited by. Anonymous clients
+// share one bucket per ip, logged in users and api tokens get their own.
+type rateLimits struct {
+	IP    ratelimit.Limit
+	User  ratelimit.Limit
+	Token ratelimit.Limit
+}
+
+// rateLimit takes cost tokens from the client's bucket for every request.
+// Expensive routes, e.g. those fanning out into many queries, use a higher
+// cost. The state of the buckets is reported in RateLimit-* headers.
+func (app *application) rateLimit(cost int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			key, limit := "ip:"+clientIP(r), app.rateLimits.IP
+			if token, ok := r.Context().Value(tokenContextKey).(*models.Token); ok {
+				key, limit = fmt.Sprintf("token:%d", token.ID), app.rateLimits.Token
+			} else if id := app.authenticatedUserID(r); id != 0 {
+				key, limit = fmt.Sprintf("user:%d", id), app.rateLimits.User
+			}
+
+			res, err := app.limiter.Take(r.Context(), key, limit, cost)
+			if err != nil {
+				// don't take the api down with the limiter
+				app.logger.Err(err).Msg(fmt.Sprintf("rate limit %s", key))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				app.clientError(w, r, http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // Create a NoSurf middleware function which uses a customized CSRF cookie with // the Secure, Path and HttpOnly attributes set.
 
 func noSurf(next http.Handler) http.Handler {
@@ -71,9 +166,47 @@ func noSurf(next http.Handler) http.Handler {
 	return csrfHandler
 }
 
+// tokenTouchInterval is how often the last use of an api token is recorded.
+const tokenTouchInterval = time.Minute
+
 func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
+		// programmatic clients send a personal API token instead of a session cookie
+		if header := r.Header.Get("Authorization"); header != "" {
+			parts := strings.Fields(header)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				app.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+
+			token, err := app.tokens.GetForToken(parts[1])
+			if err != nil {
+				if errors.Is(err, models.ErrNoRecord) {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					app.clientError(w, r, http.StatusUnauthorized)
+				} else {
+					app.serverError(w, r, err)
+				}
+				return
+			}
+
+			// last used is informational, don't write it on every request
+			if token.LastUsed == nil || time.Since(*token.LastUsed) > tokenTouchInterval {
+				err = app.tokens.Touch(token.ID)
+				if err != nil {
+					app.logger.Err(err).Msg(fmt.Sprintf("token_%d: failed to update last used", token.ID))
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+			ctx = context.WithValue(ctx, authenticatedUserIDContextKey, token.UserID)
+			ctx = context.WithValue(ctx, tokenContextKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		id := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
 		if id == 0 {
 			next.ServeHTTP(w, r)
@@ -82,15 +215,33 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 
 		exists, err := app.users.Exists(id)
 		if err != nil {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 			return
 		}
 
 		if exists {
 			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+			ctx = context.WithValue(ctx, authenticatedUserIDContextKey, id)
 			r = r.WithContext(ctx)
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// requireScope rejects requests authenticated by an API token that was not
+// granted scope. Session users are not restricted by scopes.
+func (app *application) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			token, ok := r.Context().Value(tokenContextKey).(*models.Token)
+			if ok && !token.HasScope(scope) {
+				app.clientError(w, r, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
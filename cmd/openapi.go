@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// bodyParams documents handlers which decode a JSON body of type T.
+type bodyParams[T any] struct {
+	Body T `body:"json"`
+}
+
+type idParams struct {
+	ID int `path:"id" min:"1"`
+}
+
+func (app *application) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	app.writeJSON(w, http.StatusOK, app.openapi)
+}
+
+// contractRecorder keeps a copy of the response so it can be checked
+// against the spec after the handler returned.
+type contractRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *contractRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *contractRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// checkContract validates every response of a route against the OpenAPI
+// document and logs where the handler drifted from the spec. It's enabled
+// during development with CONTRACT_CHECK=1.
+func (app *application) checkContract(method, path string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		rec := &contractRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		err := app.openapi.Check(method, path, rec.status, rec.body.Bytes())
+		if err != nil {
+			app.logger.Error().Msg(err.Error())
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bernhardson/prefoot/pkg/shared"
+)
+
+// pageParams are the query parameters of list endpoints. sort and fields
+// take the json names of the listed items, the repositories whitelist them.
+type pageParams struct {
+	Cursor string   `query:"cursor"`
+	Limit  int      `query:"limit" default:"50" min:"1" max:"200"`
+	Sort   string   `query:"sort"`
+	Fields []string `query:"fields"`
+}
+
+func (p pageParams) request() shared.PageRequest {
+	return shared.PageRequest{Cursor: p.Cursor, Limit: p.Limit, Sort: p.Sort, Fields: p.Fields}
+}
+
+// writePage writes data, a response holding the items of a page under key.
+// Link headers point to the first and the next page, the total is sent as
+// X-Total-Count where the repository counted it. With a fields parameter
+// the items are cut down to the requested fields.
+func (app *application) writePage(w http.ResponseWriter, r *http.Request, data interface{}, key, next string, total *int, p pageParams) {
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	if len(p.Fields) > 0 {
+		body, err = sparseFields(body, key, p.Fields)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Add("Link", "<"+app.pageURL(r, "")+`>; rel="first"`)
+	if next != "" {
+		w.Header().Add("Link", "<"+app.pageURL(r, next)+`>; rel="next"`)
+	}
+	if total != nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(*total))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// pageURL is the request url with cursor replaced.
+func (app *application) pageURL(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Del("cursor")
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	u := app.baseURL + r.URL.Path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
+
+// sparseFields drops all but fields from the objects of the array under key.
+func sparseFields(body []byte, key string, fields []string) ([]byte, error) {
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(resp[key], &items); err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[f] = true
+	}
+	for _, item := range items {
+		for k := range item {
+			if !keep[k] {
+				delete(item, k)
+			}
+		}
+	}
+
+	b, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	resp[key] = b
+	return json.Marshal(resp)
+}
@@ -3,35 +3,278 @@ package main
 import (
 	"net/http"
 
+	"github.com/bernhardson/prefoot/internal/models"
+	"github.com/bernhardson/prefoot/internal/openapi"
+	"github.com/bernhardson/prefoot/pkg/coach"
+	"github.com/bernhardson/prefoot/pkg/leagues"
+	"github.com/bernhardson/prefoot/pkg/metrics"
+	"github.com/bernhardson/prefoot/pkg/players"
+	"github.com/bernhardson/prefoot/pkg/referee"
+	"github.com/bernhardson/prefoot/pkg/rounds"
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/bernhardson/prefoot/pkg/team"
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
 )
 
+// route is one entry of the route table. Besides registering the handler
+// the table is used to generate the OpenAPI document, so params and
+// response must match what the handler binds and writes.
+type route struct {
+	method   string
+	path     string
+	chain    alice.Chain
+	handler  http.HandlerFunc
+	summary  string
+	tags     []string
+	params   interface{}
+	response interface{}
+	status   int
+
+	// successor is set on deprecated aliases of versioned routes, query
+	// maps the successor's path parameters to query parameter names
+	successor string
+	query     map[string]string
+}
+
+// alias registers the successor route under a legacy path.
+func alias(method, path string, successor route, query map[string]string) route {
+	successor.method, successor.path, successor.successor, successor.query = method, path, successor.path, query
+	return successor
+}
+
+func (app *application) routeTable() []route {
+
+	// request costs for the rate limiter, routes fanning out into many
+	// queries are more expensive
+	read := alice.New(app.rateLimit(1), app.requireScope(models.ScopeRead))
+	readHeavy := alice.New(app.rateLimit(5), app.requireScope(models.ScopeRead))
+	// admin routes need an admin user, api tokens also the admin scope
+	admin := alice.New(app.rateLimit(10), app.requireAuthentication, app.requireAdmin)
+	account := alice.New(app.rateLimit(1))
+	protected := account.Append(app.requireAuthentication)
+	administration := protected.Append(app.requireAdmin)
+
+	var (
+		teamByID = route{method: http.MethodGet, path: apiPrefix + "/teams/:id", chain: read, handler: app.getTeam,
+			summary: "A team", tags: []string{"teams"},
+			params: teamParams{}, response: team.TeamRow{}}
+		teamPlayers = route{method: http.MethodGet, path: apiPrefix + "/teams/:id/players", chain: read, handler: app.getPlayers,
+			summary: "Players of a team", tags: []string{"players"},
+			params: teamPageParams{}, response: shared.Page[players.PlayerRow]{}}
+		teamSquad = route{method: http.MethodGet, path: apiPrefix + "/teams/:id/squad", chain: read, handler: app.getSquad,
+			summary: "Current squad of a team with shirt numbers and positions", tags: []string{"players"},
+			params: teamParams{}, response: []*players.SquadRow{}}
+		teamStats = route{method: http.MethodGet, path: apiPrefix + "/teams/:id/statistics", chain: readHeavy, handler: app.getStatistics,
+			summary: "Players of a team joined with their match statistics", tags: []string{"players"},
+			params: teamPageParams{}, response: shared.Page[players.PlayersJoinOnPlayerStatsRow]{}}
+		// last matches per team
+		teamLast = route{method: http.MethodGet, path: apiPrefix + "/teams/:id/fixtures/last", chain: readHeavy, handler: app.getLastNFixturesByTeam,
+			summary: "Last matches of a team with their results", tags: []string{"fixtures"},
+			params: lastNFixturesParams{}, response: []*lastNFixture{}}
+		// last matchups
+		teamMatchups = route{method: http.MethodGet, path: apiPrefix + "/teams/:id/matchups/:opponent", chain: read, handler: app.getLastNMatchups,
+			summary: "Last matches between two teams", tags: []string{"fixtures"},
+			params: matchupsParams{}, response: matchups{}}
+		player = route{method: http.MethodGet, path: apiPrefix + "/players/:id", chain: read, handler: app.getPlayer,
+			summary: "A player", tags: []string{"players"},
+			params: idParams{}, response: players.Profile{}}
+		playerMetrics = route{method: http.MethodGet, path: apiPrefix + "/players/:id/metrics", chain: readHeavy, handler: app.getPlayerMetrics,
+			summary: "Per 90 values and success rates with confidence intervals of a player in a league season", tags: []string{"players"},
+			params: playerMetricsParams{}, response: metrics.PlayerRow{}}
+		playerForm = route{method: http.MethodGet, path: apiPrefix + "/players/:id/form", chain: readHeavy, handler: app.getPlayerForm,
+			summary: "Rating, minutes, goals and assists per appearance with rolling averages and streaks, as chart series", tags: []string{"players"},
+			params: playerFormParams{}, response: players.Form{}}
+		fixtureByID = route{method: http.MethodGet, path: apiPrefix + "/fixtures/:id", chain: read, handler: app.getFixtureById,
+			summary: "A fixture including both teams", tags: []string{"fixtures"},
+			params: idParams{}, response: fixtureResp{}}
+		leagueList = route{method: http.MethodGet, path: apiPrefix + "/leagues", chain: read, handler: app.getLeagues,
+			summary: "Leagues with type, country, logo and flag", tags: []string{"leagues"},
+			params: leaguesParams{}, response: shared.Page[leagues.LeagueRow]{}}
+		leagueSeasons = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons", chain: read, handler: app.getSeasons,
+			summary: "Seasons of a league with the provider's coverage", tags: []string{"leagues"},
+			params: leagueParams{}, response: []*leagues.SeasonRow{}}
+		venueList = route{method: http.MethodGet, path: apiPrefix + "/venues", chain: read, handler: app.getVenues,
+			summary: "Venues", tags: []string{"venues"},
+			params: venuesParams{}, response: shared.Page[team.VenueRow]{}}
+		venueByID = route{method: http.MethodGet, path: apiPrefix + "/venues/:id", chain: read, handler: app.getVenue,
+			summary: "A venue", tags: []string{"venues"},
+			params: idParams{}, response: team.VenueRow{}}
+		venueStats = route{method: http.MethodGet, path: apiPrefix + "/venues/:id/statistics", chain: read, handler: app.getVenueStats,
+			summary: "Home win rate and goals per game of the fixtures played at a venue", tags: []string{"venues"},
+			params: venueStatsParams{}, response: team.VenueStatsRow{}}
+		refereeList = route{method: http.MethodGet, path: apiPrefix + "/referees", chain: read, handler: app.getReferees,
+			summary: "Referees", tags: []string{"referees"},
+			params: refereesParams{}, response: shared.Page[referee.RefereeRow]{}}
+		refereeByID = route{method: http.MethodGet, path: apiPrefix + "/referees/:id", chain: read, handler: app.getReferee,
+			summary: "A referee", tags: []string{"referees"},
+			params: idParams{}, response: referee.RefereeRow{}}
+		refereeStats = route{method: http.MethodGet, path: apiPrefix + "/referees/:id/statistics", chain: read, handler: app.getRefereeStats,
+			summary: "Cards, fouls and penalties per game and home win rate of the fixtures of a referee", tags: []string{"referees"},
+			params: refereeStatsParams{}, response: referee.StatsRow{}}
+		fixtureAvailability = route{method: http.MethodGet, path: apiPrefix + "/fixtures/:id/availability", chain: read, handler: app.getAvailability,
+			summary: "Players expected to miss a fixture through injury or suspension", tags: []string{"fixtures"},
+			params: idParams{}, response: availabilityResponse{}}
+		coachByID = route{method: http.MethodGet, path: apiPrefix + "/coaches/:id", chain: read, handler: app.getCoach,
+			summary: "A coach", tags: []string{"coaches"},
+			params: idParams{}, response: coach.CoachRow{}}
+		coachCareer = route{method: http.MethodGet, path: apiPrefix + "/coaches/:id/career", chain: readHeavy, handler: app.getCoachCareer,
+			summary: "Jobs of a coach with points per game, goals and formations of each", tags: []string{"coaches"},
+			params: idParams{}, response: []*coach.Tenure{}}
+		coachBounce = route{method: http.MethodGet, path: apiPrefix + "/coaches/:id/bounce", chain: readHeavy, handler: app.getCoachBounce,
+			summary: "First matches of each job of a coach against the last matches of the predecessor", tags: []string{"coaches"},
+			params: bounceParams{}, response: []*coach.Bounce{}}
+		// ui standings table
+		standings = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/standings", chain: read, handler: app.getLeagueStanding,
+			summary: "Results and teams of a league season", tags: []string{"standings"},
+			params: leagueSeasonParams{}, response: standingResponse{}}
+		teamSeasonStats = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/teams/:team/statistics", chain: readHeavy, handler: app.getTeamSeasonStats,
+			summary: "Season totals, averages, home and away splits, recent form and league percentiles of a team's match statistics", tags: []string{"teams"},
+			params: teamSeasonStatsParams{}, response: team.SeasonStats{}}
+		leaders = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/leaders", chain: readHeavy, handler: app.getLeaders,
+			summary: "Players of a league season ranked by goals, assists, cards, ratings and more, optionally per 90 minutes", tags: []string{"players"},
+			params: leadersParams{}, response: players.Leaders{}}
+		teamMetrics = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/teams/:team/metrics", chain: readHeavy, handler: app.getTeamMetrics,
+			summary: "Per 90 values and success rates of the players of a team in a league season", tags: []string{"players"},
+			params: teamMetricsParams{}, response: []*metrics.PlayerRow{}}
+		inForm = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/form", chain: readHeavy, handler: app.getInForm,
+			summary: "Players of a league season in the best form over their last appearances", tags: []string{"players"},
+			params: inFormParams{}, response: []*players.InFormRow{}}
+		// fetchCurrentRound
+		currentRound = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/current-round", chain: read, handler: app.getRounds,
+			summary: "First round starting after ts", tags: []string{"rounds"},
+			params: roundsParams{}, response: rounds.RoundRow{}}
+		// round list
+		roundFixtures = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/rounds/:round/fixtures", chain: read, handler: app.getFixture,
+			summary: "Fixtures of a round including both teams", tags: []string{"fixtures"},
+			params: roundParams{}, response: []fixtureResp{}}
+		// key player stats
+		keyPlayers = route{method: http.MethodGet, path: apiPrefix + "/leagues/:league/seasons/:season/rounds/:round/key-players", chain: readHeavy, handler: app.getPlayerStats,
+			summary: "Key players of both teams of a match over the last rounds", tags: []string{"players"},
+			params: playerStatsParams{}, response: []*players.KeyPlayerStats{}}
+		initLeagues = route{method: http.MethodPost, path: apiPrefix + "/admin/leagues", chain: admin, handler: app.initDB,
+			summary: "Download and insert leagues with all their seasons", tags: []string{"admin"},
+			params: initParams{}}
+		refreshSeason = route{method: http.MethodPost, path: apiPrefix + "/admin/leagues/:league/seasons/:season/refresh", chain: admin, handler: app.updateDb,
+			summary: "Refresh the fixtures of the latest finished round", tags: []string{"admin"},
+			params: leagueSeasonParams{}, status: http.StatusNoContent}
+		syncSquads = route{method: http.MethodPost, path: apiPrefix + "/admin/leagues/:league/seasons/:season/squads/sync", chain: admin, handler: app.syncSquadsPost,
+			summary: "Sync the current squads of the teams of a league season and record transfers", tags: []string{"admin"},
+			params: leagueSeasonParams{}, status: http.StatusNoContent}
+	)
+
+	leagueSeason := map[string]string{"league": "league", "season": "season"}
+	leagueSeasonRound := map[string]string{"league": "league", "season": "season", "round": "round"}
+
+	return []route{
+		teamByID, teamPlayers, teamSquad, teamStats, teamLast, teamMatchups,
+		player, playerMetrics, playerForm, fixtureByID, fixtureAvailability,
+		leagueList, leagueSeasons,
+		venueList, venueByID, venueStats,
+		refereeList, refereeByID, refereeStats,
+		coachByID, coachCareer, coachBounce,
+		standings, currentRound, roundFixtures, keyPlayers, teamSeasonStats, teamMetrics, leaders, inForm,
+		initLeagues, refreshSeason, syncSquads,
+
+		// query string routes predating the versioned api
+		legacyShape(alias(http.MethodGet, "/players/", teamPlayers, map[string]string{"id": "teamId"}),
+			app.getPlayersLegacy, teamParams{}, []*players.PlayerRow{}),
+		legacyShape(alias(http.MethodGet, "/statistics/", teamStats, map[string]string{"id": "teamId"}),
+			app.getStatisticsLegacy, teamParams{}, []*legacyPlayerStatsRow{}),
+		alias(http.MethodGet, "/standings/", standings, leagueSeason),
+		alias(http.MethodGet, "/rounds/", currentRound, leagueSeason),
+		alias(http.MethodGet, "/statistics/players/", keyPlayers, leagueSeasonRound),
+		legacyShape(alias(http.MethodGet, "/fixtures/matchups/", teamMatchups, map[string]string{"id": "team1", "opponent": "team2"}),
+			app.getLastNMatchupsLegacy, matchupsParams{}, legacyMatchups{}),
+		legacyShape(alias(http.MethodGet, "/fixtures/last/", teamLast, map[string]string{"id": "team"}),
+			app.getLastNFixturesByTeamLegacy, lastNFixturesParams{}, []*legacyLastNFixture{}),
+		legacyShape(alias(http.MethodGet, "/fixtures/", roundFixtures, leagueSeasonRound),
+			app.getFixtureLegacy, roundParams{}, []legacyFixtureResp{}),
+		alias(http.MethodGet, "/init/", initLeagues, nil),
+		alias(http.MethodGet, "/updateDb/", refreshSeason, leagueSeason),
+
+		{method: http.MethodPost, path: "/user/signup", chain: account, handler: app.userSignupPost,
+			summary: "Create an account", tags: []string{"user"},
+			params: bodyParams[user]{}, status: http.StatusSeeOther},
+		{method: http.MethodPost, path: "/user/login", chain: account, handler: app.userLoginPost,
+			summary: "Log in and start a session", tags: []string{"user"},
+			params: bodyParams[user]{}},
+		{method: http.MethodPost, path: "/user/logout", chain: account, handler: app.userLogoutPost,
+			summary: "End the session", tags: []string{"user"}},
+		{method: http.MethodGet, path: "/user/verify", chain: account, handler: app.userVerify,
+			summary: "Confirm an email address", tags: []string{"user"},
+			params: verifyParams{}, status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/user/password/forgot", chain: account, handler: app.userPasswordForgotPost,
+			summary: "Send a password reset link", tags: []string{"user"},
+			params: bodyParams[passwordForgotForm]{}, status: http.StatusAccepted},
+		{method: http.MethodPost, path: "/user/password/reset", chain: account, handler: app.userPasswordResetPost,
+			summary: "Set a new password using a reset token", tags: []string{"user"},
+			params: bodyParams[passwordResetForm]{}, status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/user/verify/resend", chain: protected, handler: app.userVerifyResendPost,
+			summary: "Send a new verification link", tags: []string{"user"}, status: http.StatusAccepted},
+
+		// personal api tokens
+		{method: http.MethodPost, path: "/user/tokens", chain: protected, handler: app.tokenCreatePost,
+			summary: "Create a personal api token", tags: []string{"tokens"},
+			params: bodyParams[tokenCreateForm]{}, response: models.Token{}, status: http.StatusCreated},
+		{method: http.MethodGet, path: "/user/tokens", chain: protected, handler: app.tokenList,
+			summary: "List personal api tokens", tags: []string{"tokens"},
+			response: []*models.Token{}},
+		{method: http.MethodDelete, path: "/user/tokens/:id", chain: protected, handler: app.tokenRevoke,
+			summary: "Revoke a personal api token", tags: []string{"tokens"},
+			params: idParams{}, status: http.StatusNoContent},
+
+		{method: http.MethodPost, path: "/admin/login/unlock", chain: administration, handler: app.adminLoginUnlockPost,
+			summary: "Lift a login lockout", tags: []string{"admin"},
+			params: bodyParams[unlockForm]{}, status: http.StatusNoContent},
+	}
+}
+
 func (app *application) routes() http.Handler {
 	router := httprouter.New()
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.notFound(w, r)
+	})
+	router.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.clientError(w, r, http.StatusMethodNotAllowed)
+	})
 
-	router.HandlerFunc(http.MethodGet, "/players/", app.getPlayers)
-	router.HandlerFunc(http.MethodGet, "/statistics/", app.getStatistics)
-	// ui standings table
-	router.HandlerFunc(http.MethodGet, "/standings/", app.getLeagueStanding)
-	// fetchCurrentRound
-	router.HandlerFunc(http.MethodGet, "/rounds/", app.getRounds)
-	// key player stats
-	router.HandlerFunc(http.MethodGet, "/statistics/players/", app.getPlayerStats)
-	// last matchups
-	router.HandlerFunc(http.MethodGet, "/fixtures/matchups/", app.getLastNMatchups)
-	// last matches per team
-	router.HandlerFunc(http.MethodGet, "/fixtures/last/", app.getLastNFixturesByTeam)
-	// round list
-	router.HandlerFunc(http.MethodGet, "/fixtures/", app.getFixture)
-	router.HandlerFunc(http.MethodGet, "/init/", app.initDB)
-	router.HandlerFunc(http.MethodGet, "/updateDb/", app.updateDb)
-
-	router.HandlerFunc(http.MethodPost, "/user/signup", app.userSignupPost)
-	router.HandlerFunc(http.MethodPost, "/user/login", app.userLoginPost)
-	router.HandlerFunc(http.MethodPost, "/user/logout", app.userLogoutPost)
-
-	standard := alice.New(app.sessionManager.LoadAndSave, app.recoverPanic, app.logRequest, secureHeaders)
-	//protected := standard.Append(app.requireAuthentication)
+	table := app.routeTable()
+	app.openapi = openapi.Generate("prefoot api", "1.0.0", problem{}, operations(table))
+
+	for _, rt := range table {
+		chain := rt.chain
+		if rt.successor != "" {
+			chain = alice.New(app.legacyAlias(rt.successor, rt.query)).Extend(chain)
+		}
+		h := chain.ThenFunc(rt.handler)
+		if app.contractCheck {
+			h = app.checkContract(rt.method, rt.path, h)
+		}
+		router.Handler(rt.method, rt.path, h)
+	}
+
+	router.HandlerFunc(http.MethodGet, "/openapi.json", app.getOpenAPI)
+	router.Handler(http.MethodGet, "/docs/*filepath", http.StripPrefix("/docs", openapi.UI()))
+
+	standard := alice.New(app.sessionManager.LoadAndSave, app.recoverPanic, app.logRequest, secureHeaders, app.authenticate)
 	return standard.Then(router)
 }
+
+func operations(table []route) []openapi.Operation {
+	ops := make([]openapi.Operation, 0, len(table))
+	for _, rt := range table {
+		ops = append(ops, openapi.Operation{
+			Method:     rt.method,
+			Path:       rt.path,
+			Summary:    rt.summary,
+			Tags:       rt.tags,
+			Params:     rt.params,
+			Response:   rt.response,
+			Status:     rt.status,
+			Deprecated: rt.successor != "",
+			Query:      rt.query,
+		})
+	}
+	return ops
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bernhardson/prefoot/internal/models"
+	"github.com/bernhardson/prefoot/internal/validator"
+)
+
+// loginThrottleKeys returns the keys failed logins are tracked by, one for
+// the client ip and one for the account.
+func loginThrottleKeys(r *http.Request, email string) (string, string) {
+	return "ip:" + clientIP(r), "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// reserveLogin reserves a login attempt of the ip and the account. If
+// either has to wait, no attempt is reserved and the longer wait returned.
+func (app *application) reserveLogin(ipKey, accountKey string) (time.Duration, error) {
+
+	wait, err := app.loginThrottle.Reserve(models.IPThrottle, ipKey)
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+	wait, err = app.loginThrottle.Reserve(models.AccountThrottle, accountKey)
+	if err != nil || wait > 0 {
+		if err := app.loginThrottle.Release(models.IPThrottle, ipKey); err != nil {
+			app.logger.Err(err).Msg(fmt.Sprintf("failed to release login attempt %s", ipKey))
+		}
+	}
+	return wait, err
+}
+
+// releaseLogin gives back the attempts of a login that was not decided.
+func (app *application) releaseLogin(ipKey, accountKey string) {
+
+	err := app.loginThrottle.Release(models.IPThrottle, ipKey)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("failed to release login attempt %s", ipKey))
+	}
+	err = app.loginThrottle.Release(models.AccountThrottle, accountKey)
+	if err != nil {
+		app.logger.Err(err).Msg(fmt.Sprintf("failed to release login attempt %s", accountKey))
+	}
+}
+
+type unlockForm struct {
+	Email               string `json:"email"`
+	IP                  string `json:"ip"`
+	validator.Validator `json:"-"`
+}
+
+// lifts a login lockout of an account and/or an ip address
+func (app *application) adminLoginUnlockPost(w http.ResponseWriter, r *http.Request) {
+
+	var form unlockForm
+	err := json.NewDecoder(r.Body).Decode(&form)
+	if err != nil {
+		app.badRequest(w, r, "failed to decode JSON payload")
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email) || validator.NotBlank(form.IP), "email", "Either email or ip is required")
+	if form.IP != "" {
+		form.CheckField(net.ParseIP(form.IP) != nil, "ip", "This field must be a valid ip address")
+	}
+	if !form.Valid() {
+		app.failedValidation(w, r, form.Validator)
+		return
+	}
+
+	var keys []string
+	if form.Email != "" {
+		_, accountKey := loginThrottleKeys(r, form.Email)
+		keys = append(keys, accountKey)
+	}
+	if form.IP != "" {
+		keys = append(keys, "ip:"+form.IP)
+	}
+
+	unlocked := 0
+	for _, key := range keys {
+		err := app.loginThrottle.Unlock(key)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				continue
+			}
+			app.serverError(w, r, err)
+			return
+		}
+		app.logger.Info().Msg(fmt.Sprintf("user_%d unlocked %s", app.authenticatedUserID(r), key))
+		unlocked++
+	}
+
+	if unlocked == 0 {
+		app.notFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
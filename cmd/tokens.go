@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bernhardson/prefoot/internal/binding"
+	"github.com/bernhardson/prefoot/internal/models"
+	"github.com/bernhardson/prefoot/internal/validator"
+)
+
+const (
+	defaultTokenTTL = 30 * 24 * time.Hour
+	maxTokenTTL     = 365 * 24 * time.Hour
+)
+
+type tokenCreateForm struct {
+	Name                string   `json:"name"`
+	Scopes              []string `json:"scopes"`
+	TTLHours            int      `json:"ttl_hours"`
+	validator.Validator `json:"-"`
+}
+
+// creates a personal API token for the logged in user
+// the plaintext token is only part of this response
+func (app *application) tokenCreatePost(w http.ResponseWriter, r *http.Request) {
+
+	var form tokenCreateForm
+	err := json.NewDecoder(r.Body).Decode(&form)
+	if err != nil {
+		app.badRequest(w, r, "failed to decode JSON payload")
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 255), "name", "This field cannot be more than 255 characters long")
+	form.CheckField(len(form.Scopes) > 0, "scopes", "At least one scope is required")
+	for _, s := range form.Scopes {
+		form.CheckField(validator.PermittedValue(s, models.Scopes...), "scopes", "Unknown scope "+s)
+	}
+	// checked before the conversion, large values overflow the duration
+	form.CheckField(form.TTLHours == 0 || (form.TTLHours >= 1 && form.TTLHours <= int(maxTokenTTL/time.Hour)),
+		"ttl_hours", "Token lifetime must be between 1 hour and 1 year")
+
+	// only admins may hand out the admin scope
+	for _, s := range form.Scopes {
+		if s == models.ScopeAdmin {
+			user, err := app.users.Get(app.authenticatedUserID(r))
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			form.CheckField(user.Admin, "scopes", "Scope admin requires an admin account")
+		}
+	}
+
+	// a token can't be used to mint a token with more privileges than itself
+	if token, ok := r.Context().Value(tokenContextKey).(*models.Token); ok {
+		for _, s := range form.Scopes {
+			form.CheckField(token.HasScope(s), "scopes", "Scope "+s+" exceeds the scopes of the current token")
+		}
+	}
+
+	if !form.Valid() {
+		app.failedValidation(w, r, form.Validator)
+		return
+	}
+
+	ttl := defaultTokenTTL
+	if form.TTLHours != 0 {
+		ttl = time.Duration(form.TTLHours) * time.Hour
+	}
+
+	token, err := app.tokens.New(app.authenticatedUserID(r), form.Name, ttl, form.Scopes)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, token)
+}
+
+// lists the tokens of the logged in user without their plaintext
+func (app *application) tokenList(w http.ResponseWriter, r *http.Request) {
+
+	tokens, err := app.tokens.GetAllForUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, tokens)
+}
+
+func (app *application) tokenRevoke(w http.ResponseWriter, r *http.Request) {
+
+	var p idParams
+	if err := binding.Bind(r, &p); err != nil {
+		app.errorResponse(w, r, err)
+		return
+	}
+
+	err := app.tokens.Delete(p.ID, app.authenticatedUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
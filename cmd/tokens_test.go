@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestTokenCreateTTL checks that lifetimes outside of 1 hour to 1 year are
+// rejected before anything is stored, including ones whose duration
+// overflows into that range.
+func TestTokenCreateTTL(t *testing.T) {
+
+	app, _ := newTestApplication(nil)
+
+	for _, hours := range []int{-1, 8761, 5124096, 1 << 62} {
+		t.Run(strconv.Itoa(hours), func(t *testing.T) {
+			body := `{"name": "ci", "scopes": ["read"], "ttl_hours": ` + strconv.Itoa(hours) + `}`
+			rec := httptest.NewRecorder()
+			app.tokenCreatePost(rec, httptest.NewRequest(http.MethodPost, "/user/tokens", strings.NewReader(body)))
+
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+			}
+			var p problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := p.Errors["ttl_hours"]; !ok {
+				t.Errorf("errors %v, want one for ttl_hours", p.Errors)
+			}
+		})
+	}
+}
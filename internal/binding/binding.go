@@ -0,0 +1,221 @@
+// Package binding fills request structs from query parameters, httprouter
+// path parameters and JSON bodies and validates them declaratively.
+//
+// Fields are described by struct tags:
+//
+//	query:"league"    read from the query string
+//	path:"id"         read from the httprouter path parameters, always required
+//	body:"json"       decode the JSON request body into the field
+//	required:"true"   the parameter must be present
+//	default:"5"       value used when the parameter is absent
+//	min:"1" max:"50"  bounds of numeric values or the length of strings
+//	enum:"asc,desc"   permitted values
+//
+// Supported field types are string, bool, int, int64, float64 and []int,
+// []string given as comma separated lists.
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bernhardson/prefoot/internal/validator"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Error aggregates all problems found while binding a request. Malformed is
+// set if at least one value could not be parsed at all, as opposed to values
+// that were parsed but failed validation.
+type Error struct {
+	validator.Validator
+	Malformed bool
+}
+
+func (e *Error) Error() string {
+	keys := make([]string, 0, len(e.FieldErrors))
+	for k, v := range e.FieldErrors {
+		keys = append(keys, fmt.Sprintf("%s: %s", k, v))
+	}
+	return "binding: " + strings.Join(append(keys, e.NonFieldErrors...), "; ")
+}
+
+// Bind fills dst, a pointer to a struct, from r and validates it. The
+// returned error is an *Error if the request was at fault.
+func Bind(r *http.Request, dst interface{}) error {
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("binding: dst must be a pointer to a struct")
+	}
+
+	berr := &Error{}
+	bindStruct(r, rv.Elem(), berr)
+
+	if !berr.Valid() {
+		return berr
+	}
+	return nil
+}
+
+// bindStruct binds the fields of rv, descending into embedded structs so
+// common parameters can be shared between request types.
+func bindStruct(r *http.Request, rv reflect.Value, berr *Error) {
+
+	params := httprouter.ParamsFromContext(r.Context())
+	query := r.URL.Query()
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			bindStruct(r, fv, berr)
+			continue
+		}
+
+		if _, ok := f.Tag.Lookup("body"); ok {
+			err := json.NewDecoder(r.Body).Decode(fv.Addr().Interface())
+			if err != nil {
+				berr.Malformed = true
+				berr.AddNonFieldError("body must be valid JSON")
+			}
+			continue
+		}
+
+		name, raw, present := "", "", false
+		if n, ok := f.Tag.Lookup("path"); ok {
+			name = n
+			raw = params.ByName(n)
+			present = raw != ""
+		} else if n, ok := f.Tag.Lookup("query"); ok {
+			name = n
+			present = query.Has(n) && query.Get(n) != ""
+			raw = query.Get(n)
+		} else {
+			continue
+		}
+
+		if !present {
+			if f.Tag.Get("required") == "true" || f.Tag.Get("path") != "" {
+				berr.AddFieldError(name, "This parameter is required")
+				continue
+			}
+			def, ok := f.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			raw = def
+		}
+
+		err := set(fv, raw)
+		if err != nil {
+			berr.Malformed = true
+			berr.AddFieldError(name, err.Error())
+			continue
+		}
+
+		check(berr, name, f.Tag, fv)
+	}
+}
+
+func set(fv reflect.Value, raw string) error {
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("This parameter must be a boolean")
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return errors.New("This parameter must be an integer")
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("This parameter must be a number")
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		parts := strings.Split(raw, ",")
+		s := reflect.MakeSlice(fv.Type(), 0, len(parts))
+		for _, p := range parts {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if ev.Kind() == reflect.Slice {
+				return errors.New("nested lists are not supported")
+			}
+			if err := set(ev, p); err != nil {
+				return errors.New(strings.Replace(err.Error(), "This parameter must be", "All values must be", 1))
+			}
+			s = reflect.Append(s, ev)
+		}
+		fv.Set(s)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+func check(berr *Error, name string, tag reflect.StructTag, fv reflect.Value) {
+
+	if enum, ok := tag.Lookup("enum"); ok {
+		permitted := strings.Split(enum, ",")
+		values := []string{fmt.Sprint(fv.Interface())}
+		if fv.Kind() == reflect.Slice {
+			values = values[:0]
+			for i := 0; i < fv.Len(); i++ {
+				values = append(values, fmt.Sprint(fv.Index(i).Interface()))
+			}
+		}
+		for _, v := range values {
+			berr.CheckField(validator.PermittedValue(v, permitted...), name, "This parameter must be one of "+strings.Join(permitted, ", "))
+		}
+	}
+
+	for _, bound := range []string{"min", "max"} {
+		b, ok := tag.Lookup(bound)
+		if !ok {
+			continue
+		}
+		limit, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			panic(fmt.Sprintf("binding: invalid %s tag %q on %s", bound, b, name))
+		}
+
+		var v float64
+		unit := ""
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int64:
+			v = float64(fv.Int())
+		case reflect.Float64:
+			v = fv.Float()
+		case reflect.String:
+			v, unit = float64(len([]rune(fv.String()))), " characters"
+		case reflect.Slice:
+			v, unit = float64(fv.Len()), " values"
+		default:
+			continue
+		}
+
+		if bound == "min" {
+			berr.CheckField(v >= limit, name, fmt.Sprintf("This parameter must be at least %s%s", b, unit))
+		} else {
+			berr.CheckField(v <= limit, name, fmt.Sprintf("This parameter must be at most %s%s", b, unit))
+		}
+	}
+}
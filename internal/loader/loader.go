@@ -0,0 +1,85 @@
+// Package loader batches lookups by key in the style of a dataloader.
+// Handlers resolve the keys they need with LoadMany, the uncached ones are
+// fetched with one call to the batch function. Results are cached for the
+// lifetime of the loader, so loaders are created per request.
+package loader
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned by Load for keys the batch function did not
+// return a value for.
+var ErrNotFound = errors.New("loader: no value for key")
+
+// BatchFunc fetches the values of keys. Keys without a value are left out
+// of the map.
+type BatchFunc[K comparable, V any] func(keys []K) (map[K]V, error)
+
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	cache   map[K]V
+}
+
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:  batch,
+		queued: make(map[K]bool),
+		cache:  make(map[K]V),
+	}
+}
+
+// Load returns the value of key, fetching it unless it is cached.
+func (l *Loader[K, V]) Load(key K) (V, error) {
+	vs, err := l.LoadMany(key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return vs[key], nil
+}
+
+// LoadMany returns the values of keys, fetching the uncached ones in one
+// batch. It fails with ErrNotFound if any key has no value.
+func (l *Loader[K, V]) LoadMany(keys ...K) (map[K]V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.queue(keys)
+	if len(l.pending) > 0 {
+		vs, err := l.batch(l.pending)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vs {
+			l.cache[k] = v
+		}
+		l.pending = nil
+		l.queued = make(map[K]bool)
+	}
+
+	res := make(map[K]V, len(keys))
+	for _, k := range keys {
+		v, ok := l.cache[k]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (l *Loader[K, V]) queue(keys []K) {
+	for _, k := range keys {
+		if _, ok := l.cache[k]; ok || l.queued[k] {
+			continue
+		}
+		l.queued[k] = true
+		l.pending = append(l.pending, k)
+	}
+}
@@ -0,0 +1,72 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Mailer sends plain text emails to a single recipient.
+type Mailer interface {
+	Send(recipient, subject, body string) error
+}
+
+// SMTPMailer delivers mails through an SMTP server using PLAIN auth.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Sender   string
+}
+
+func (m *SMTPMailer) Send(recipient, subject, body string) error {
+
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(addr, auth, m.Sender, []string{recipient}, message(m.Sender, recipient, subject, body))
+}
+
+// LogMailer is used for local development and tests. Mails are written to
+// the logger and, if Dir is set, stored as .eml files in Dir.
+type LogMailer struct {
+	Logger *zerolog.Logger
+	Sender string
+	Dir    string
+}
+
+func (m *LogMailer) Send(recipient, subject, body string) error {
+
+	m.Logger.Info().Msg(fmt.Sprintf("mail to=%s#subject=%s\n%s", recipient, subject, body))
+	if m.Dir == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(m.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.ReplaceAll(recipient, "@", "_at_"))
+	return os.WriteFile(filepath.Join(m.Dir, name), message(m.Sender, recipient, subject, body), 0o644)
+}
+
+func message(sender, recipient, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", sender)
+	fmt.Fprintf(&b, "To: %s\r\n", recipient)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
@@ -14,4 +14,9 @@ var (
 	// Add a new ErrDuplicateEmail error. We'll use this later if a user // tries to signup with an email address that's already in use.
 
 	ErrDuplicateEmail = errors.New("models: duplicate email")
+
+	// ErrInvalidToken is returned for unknown, expired or already used
+	// verification and password reset tokens.
+
+	ErrInvalidToken = errors.New("models: invalid or expired token")
 )
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	insertLoginAttempt = `INSERT INTO login_attempts (key, failures, last_failure) VALUES ($1, 0, CURRENT_TIMESTAMP)
+							ON CONFLICT (key) DO NOTHING`
+	selectLoginAttempt = `SELECT failures, last_failure, locked_until FROM login_attempts WHERE key = $1 FOR UPDATE`
+	updateLoginAttempt = `UPDATE login_attempts SET failures = $2, last_failure = CURRENT_TIMESTAMP,
+							locked_until = CASE WHEN $3 THEN CURRENT_TIMESTAMP + $4::interval ELSE locked_until END
+							WHERE key = $1`
+	releaseLogin = `UPDATE login_attempts SET failures = greatest(failures - 1, 0),
+							locked_until = CASE WHEN failures - 1 < $2 THEN NULL ELSE locked_until END
+							WHERE key = $1`
+	deleteLogin = `DELETE FROM login_attempts WHERE key = $1`
+)
+
+// ThrottlePolicy describes when repeated login failures of one key, e.g.
+// an ip address or an account, are slowed down and locked.
+type ThrottlePolicy struct {
+	// failures before delays start
+	FreeAttempts int
+	// upper bound of the progressive delay
+	MaxDelay time.Duration
+	// failures after which the key is locked
+	LockAfter int
+	LockFor   time.Duration
+	// failures older than Window are forgotten
+	Window time.Duration
+}
+
+var (
+	AccountThrottle = ThrottlePolicy{FreeAttempts: 3, MaxDelay: time.Minute, LockAfter: 10, LockFor: 15 * time.Minute, Window: time.Hour}
+	IPThrottle      = ThrottlePolicy{FreeAttempts: 10, MaxDelay: time.Minute, LockAfter: 100, LockFor: time.Hour, Window: time.Hour}
+)
+
+// delay doubles with every failure beyond the free attempts
+func (p ThrottlePolicy) delay(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+	d := time.Duration(math.Pow(2, float64(failures-p.FreeAttempts))) * time.Second
+	if d > p.MaxDelay || d <= 0 {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// LoginThrottleModel tracks failed logins in postgres so throttling works
+// across several instances.
+type LoginThrottleModel struct {
+	Pool *pgxpool.Pool
+}
+
+// Reserve counts a login attempt for key as failed before the password is
+// checked, so concurrent attempts can't all pass the same check. If the
+// attempt is not accepted yet, nothing is counted and the time to wait is
+// returned. Successful attempts give the reservation back with Release or
+// Reset.
+func (m *LoginThrottleModel) Reserve(policy ThrottlePolicy, key string) (time.Duration, error) {
+
+	ctx := context.Background()
+	tx, err := m.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the row lock serialises attempts of the same key
+	if _, err := tx.Exec(ctx, insertLoginAttempt, key); err != nil {
+		return 0, err
+	}
+	var failures int
+	var last time.Time
+	var locked *time.Time
+	err = tx.QueryRow(ctx, selectLoginAttempt, key).Scan(&failures, &last, &locked)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if wait := policy.wait(failures, last, locked, now); wait > 0 {
+		return wait, nil
+	}
+
+	if last.Before(now.Add(-policy.Window)) {
+		failures = 0
+	}
+	failures++
+	_, err = tx.Exec(ctx, updateLoginAttempt, key, failures, failures >= policy.LockAfter, policy.LockFor)
+	if err != nil {
+		return 0, err
+	}
+	return 0, tx.Commit(ctx)
+}
+
+// wait returns how long a key with failures, the last one at last, has to
+// wait at now.
+func (p ThrottlePolicy) wait(failures int, last time.Time, locked *time.Time, now time.Time) time.Duration {
+
+	var wait time.Duration
+	if locked != nil && locked.After(now) {
+		wait = locked.Sub(now)
+	}
+	if last.After(now.Add(-p.Window)) {
+		if d := last.Add(p.delay(failures)).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// Release gives back an attempt reserved for key that did not fail, e.g.
+// a successful login from an ip shared with others.
+func (m *LoginThrottleModel) Release(policy ThrottlePolicy, key string) error {
+	_, err := m.Pool.Exec(context.Background(), releaseLogin, key, policy.LockAfter)
+	return err
+}
+
+// Reset forgets all failures of key, e.g. after a successful login.
+func (m *LoginThrottleModel) Reset(key string) error {
+	_, err := m.Pool.Exec(context.Background(), deleteLogin, key)
+	return err
+}
+
+// Unlock lifts a lock manually. ErrNoRecord is returned if key has no
+// recorded failures.
+func (m *LoginThrottleModel) Unlock(key string) error {
+
+	res, err := m.Pool.Exec(context.Background(), deleteLogin, key)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
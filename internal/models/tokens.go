@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Scopes a personal API token can be granted.
+const (
+	ScopeRead    = "read"
+	ScopePredict = "predict"
+	ScopeAdmin   = "admin"
+)
+
+var Scopes = []string{ScopeRead, ScopePredict, ScopeAdmin}
+
+const (
+	insertToken        = `INSERT INTO tokens (user_id, name, hash, scopes, expiry) VALUES ($1, $2, $3, $4, $5) RETURNING id, created`
+	selectTokenByHash  = `SELECT id, user_id, name, scopes, created, expiry, last_used FROM tokens WHERE hash = $1 AND expiry > CURRENT_TIMESTAMP`
+	selectTokensByUser = `SELECT id, user_id, name, scopes, created, expiry, last_used FROM tokens WHERE user_id = $1 ORDER BY created DESC`
+	deleteToken        = `DELETE FROM tokens WHERE id = $1 AND user_id = $2`
+	updateTokenUsed    = `UPDATE tokens SET last_used = CURRENT_TIMESTAMP WHERE id = $1`
+)
+
+// Token is a personal API token. Only the SHA-256 hash is stored, the
+// plaintext is returned once on creation.
+type Token struct {
+	ID        int        `json:"id"`
+	Plaintext string     `json:"token,omitempty"`
+	UserID    int        `json:"-"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	Created   time.Time  `json:"created"`
+	Expiry    time.Time  `json:"expiry"`
+	LastUsed  *time.Time `json:"last_used"`
+}
+
+// HasScope reports whether the token was granted scope. The admin scope
+// implies every other scope.
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenModel struct {
+	Pool *pgxpool.Pool
+}
+
+func hashToken(plaintext string) []byte {
+	h := sha256.Sum256([]byte(plaintext))
+	return h[:]
+}
+
+// New generates a random token for the user, stores its hash and returns
+// the token including the plaintext.
+func (m *TokenModel) New(userID int, name string, ttl time.Duration, scopes []string) (*Token, error) {
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	t := &Token{
+		Plaintext: base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		Expiry:    time.Now().Add(ttl),
+	}
+
+	err := m.Pool.QueryRow(context.Background(), insertToken,
+		t.UserID, t.Name, hashToken(t.Plaintext), t.Scopes, t.Expiry).Scan(&t.ID, &t.Created)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetForToken returns the unexpired token matching plaintext or ErrNoRecord.
+func (m *TokenModel) GetForToken(plaintext string) (*Token, error) {
+
+	t := &Token{}
+	err := m.Pool.QueryRow(context.Background(), selectTokenByHash, hashToken(plaintext)).
+		Scan(&t.ID, &t.UserID, &t.Name, &t.Scopes, &t.Created, &t.Expiry, &t.LastUsed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (m *TokenModel) GetAllForUser(userID int) ([]*Token, error) {
+
+	rows, err := m.Pool.Query(context.Background(), selectTokensByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*Token{}
+	for rows.Next() {
+		t := &Token{}
+		err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Scopes, &t.Created, &t.Expiry, &t.LastUsed)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// Delete revokes a token. Users can only revoke their own tokens.
+func (m *TokenModel) Delete(id, userID int) error {
+
+	res, err := m.Pool.Exec(context.Background(), deleteToken, id, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
+
+// Touch records that the token has just been used.
+func (m *TokenModel) Touch(id int) error {
+	_, err := m.Pool.Exec(context.Background(), updateTokenUsed, id)
+	return err
+}
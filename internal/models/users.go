@@ -2,22 +2,25 @@ package models
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"strings"
 	"time"
 
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserModelInterface interface {
-	Insert(name, email, password string) error
+	Insert(name, email, password string) (int, error)
 	Authenticate(email, password string) (int, error)
 	Exists(id int) (bool, error)
 	Get(id int) (*User, error)
+	GetByEmail(email string) (*User, error)
 	PasswordUpdate(id int, currentPassword, newPassword string) error
+	PasswordSet(id int, newPassword string) error
+	SetVerified(id int) error
 }
 
 type User struct {
@@ -25,6 +28,8 @@ type User struct {
 	Name           string
 	Email          string
 	HashedPassword []byte
+	Verified       bool
+	Admin          bool
 	Created        time.Time
 }
 
@@ -35,11 +40,11 @@ type UserModel struct {
 func (m *UserModel) Get(id int) (*User, error) {
 	var user User
 
-	stmt := `SELECT id, name, email, created FROM users WHERE id = ?`
+	stmt := `SELECT id, name, email, verified, admin, created FROM users WHERE id = $1`
 
-	err := m.Pool.QueryRow(context.Background(), stmt, id).Scan(&user.ID, &user.Name, &user.Email, &user.Created)
+	err := m.Pool.QueryRow(context.Background(), stmt, id).Scan(&user.ID, &user.Name, &user.Email, &user.Verified, &user.Admin, &user.Created)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNoRecord
 		} else {
 			return nil, err
@@ -49,29 +54,48 @@ func (m *UserModel) Get(id int) (*User, error) {
 	return &user, nil
 }
 
-func (m *UserModel) Insert(name, email, password string) error {
+func (m *UserModel) GetByEmail(email string) (*User, error) {
+	var user User
+
+	stmt := `SELECT id, name, email, verified, admin, created FROM users WHERE email = $1`
+
+	err := m.Pool.QueryRow(context.Background(), stmt, email).Scan(&user.ID, &user.Name, &user.Email, &user.Verified, &user.Admin, &user.Created)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		} else {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// Insert creates an unverified user and returns its id.
+func (m *UserModel) Insert(name, email, password string) (int, error) {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	stmt := `INSERT INTO users (name, email, hashed_password, created)
-    VALUES($1, $2, $3, CURRENT_TIMESTAMP)`
+    VALUES($1, $2, $3, CURRENT_TIMESTAMP) RETURNING id`
 
-	_, err = m.Pool.Exec(context.Background(), stmt, name, email, string(hashedPassword))
+	var id int
+	err = m.Pool.QueryRow(context.Background(), stmt, name, email, string(hashedPassword)).Scan(&id)
 	if err != nil {
 
-		var pgError *pgx.PgError
+		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) {
-			if pgError.Code == "23505" && strings.Contains(pgError.Message, "users_uc_email") {
-				return ErrDuplicateEmail
+			if pgError.Code == "23505" && strings.Contains(pgError.ConstraintName, "users_uc_email") {
+				return 0, ErrDuplicateEmail
 			}
 		}
-		return err
+		return 0, err
 	}
 
-	return nil
+	return id, nil
 }
 
 func (m *UserModel) Authenticate(email, password string) (int, error) {
@@ -79,11 +103,11 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 	var id int
 	var hashedPassword []byte
 
-	stmt := "SELECT id, hashed_password FROM users WHERE email = ?"
+	stmt := "SELECT id, hashed_password FROM users WHERE email = $1"
 
 	err := m.Pool.QueryRow(context.Background(), stmt, email).Scan(&id, &hashedPassword)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, ErrInvalidCredentials
 		} else {
 			return 0, err
@@ -114,7 +138,7 @@ func (m *UserModel) Exists(id int) (bool, error) {
 func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string) error {
 	var currentHashedPassword []byte
 
-	stmt := "SELECT hashed_password FROM users WHERE id = ?"
+	stmt := "SELECT hashed_password FROM users WHERE id = $1"
 
 	err := m.Pool.QueryRow(context.Background(), stmt, id).Scan(&currentHashedPassword)
 	if err != nil {
@@ -130,13 +154,28 @@ func (m *UserModel) PasswordUpdate(id int, currentPassword, newPassword string)
 		}
 	}
 
+	return m.PasswordSet(id, newPassword)
+}
+
+// PasswordSet replaces the password without checking the current one.
+// Callers must have verified the user otherwise, e.g. by a reset token.
+func (m *UserModel) PasswordSet(id int, newPassword string) error {
+
 	newHashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), 12)
 	if err != nil {
 		return err
 	}
 
-	stmt = "UPDATE users SET hashed_password = ? WHERE id = ?"
+	stmt := "UPDATE users SET hashed_password = $1 WHERE id = $2"
 
 	_, err = m.Pool.Exec(context.Background(), stmt, string(newHashedPassword), id)
 	return err
 }
+
+func (m *UserModel) SetVerified(id int) error {
+
+	stmt := "UPDATE users SET verified = true WHERE id = $1"
+
+	_, err := m.Pool.Exec(context.Background(), stmt, id)
+	return err
+}
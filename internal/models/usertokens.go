@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Purposes of single-use user tokens sent by mail.
+const (
+	PurposeVerification  = "verification"
+	PurposePasswordReset = "password_reset"
+)
+
+const (
+	insertUserToken  = `INSERT INTO user_tokens (hash, user_id, purpose, expiry) VALUES ($1, $2, $3, $4)`
+	consumeUserToken = `UPDATE user_tokens SET used = CURRENT_TIMESTAMP
+						WHERE hash = $1 AND purpose = $2 AND used IS NULL AND expiry > CURRENT_TIMESTAMP
+						RETURNING user_id`
+	deleteUserTokens = `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND used IS NULL`
+)
+
+// UserTokenModel manages single-use, expiring tokens for email
+// verification and password resets.
+type UserTokenModel struct {
+	Pool *pgxpool.Pool
+}
+
+// New stores a token for the user and returns its plaintext.
+func (m *UserTokenModel) New(userID int, purpose string, ttl time.Duration) (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+
+	_, err := m.Pool.Exec(context.Background(), insertUserToken,
+		hashToken(plaintext), userID, purpose, time.Now().Add(ttl))
+	if err != nil {
+		return "", err
+	}
+	return plaintext, nil
+}
+
+// Consume marks a valid token as used and returns the user it belongs to.
+// Expired, unknown or already used tokens return ErrInvalidToken.
+func (m *UserTokenModel) Consume(plaintext, purpose string) (int, error) {
+
+	var userID int
+	err := m.Pool.QueryRow(context.Background(), consumeUserToken, hashToken(plaintext), purpose).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrInvalidToken
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// DeleteAllForUser removes unused tokens of purpose, e.g. other pending
+// reset links once the password was changed.
+func (m *UserTokenModel) DeleteAllForUser(userID int, purpose string) error {
+	_, err := m.Pool.Exec(context.Background(), deleteUserTokens, userID, purpose)
+	return err
+}
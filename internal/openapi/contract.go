@@ -0,0 +1,128 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Check validates a response body against the document. It returns an error
+// describing every place where the response drifted from the spec, e.g.
+// missing or undocumented properties and mismatching types.
+func (d *Document) Check(method, path string, status int, body []byte) error {
+
+	item, ok := d.Paths[OpenAPIPath(path)][strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("%s %s is not part of the spec", method, path)
+	}
+
+	resp, ok := item.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp = item.Responses["default"]
+	}
+	if len(resp.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("%s %s: status %d documents no content", method, path, status)
+		}
+		return nil
+	}
+
+	var schema *Schema
+	for _, mt := range resp.Content {
+		schema = mt.Schema
+	}
+
+	var v interface{}
+	err := json.Unmarshal(body, &v)
+	if err != nil {
+		return fmt.Errorf("%s %s: invalid json: %w", method, path, err)
+	}
+
+	var drift []string
+	d.validate(schema, v, "$", &drift)
+	if len(drift) > 0 {
+		return fmt.Errorf("%s %s drifted from spec:\n\t%s", method, path, strings.Join(drift, "\n\t"))
+	}
+	return nil
+}
+
+func (d *Document) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+func (d *Document) validate(s *Schema, v interface{}, at string, drift *[]string) {
+
+	s = d.resolve(s)
+	if v == nil {
+		// Go encodes nil slices, maps and pointers as null
+		if !s.Nullable && s.Type != "array" && s.Type != "object" && s.Type != "" {
+			*drift = append(*drift, fmt.Sprintf("%s: null but %s is not nullable", at, s.Type))
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: expected object, got %T", at, v))
+			return
+		}
+		if s.AdditionalProperties != nil {
+			for k, e := range m {
+				d.validate(s.AdditionalProperties, e, at+"."+k, drift)
+			}
+			return
+		}
+		for _, r := range s.Required {
+			if _, ok := m[r]; !ok {
+				*drift = append(*drift, fmt.Sprintf("%s: missing property %s", at, r))
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps, ok := s.Properties[k]
+			if !ok {
+				*drift = append(*drift, fmt.Sprintf("%s: undocumented property %s", at, k))
+				continue
+			}
+			d.validate(ps, m[k], at+"."+k, drift)
+		}
+	case "array":
+		a, ok := v.([]interface{})
+		if !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: expected array, got %T", at, v))
+			return
+		}
+		for i, e := range a {
+			d.validate(s.Items, e, fmt.Sprintf("%s[%d]", at, i), drift)
+		}
+	case "integer":
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) {
+			*drift = append(*drift, fmt.Sprintf("%s: expected integer, got %v", at, v))
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: expected number, got %T", at, v))
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: expected string, got %T", at, v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			*drift = append(*drift, fmt.Sprintf("%s: expected boolean, got %T", at, v))
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package openapi generates an OpenAPI 3 document from the route table and
+// the Go types of parameters and responses.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operation describes one route. Params is a struct with binding tags
+// (query, path, body, required, default, min, max, enum), Response a value of
+// the type written on success. Query lists path parameters that the route
+// reads from the query string instead, keyed by path parameter name; it is
+// used for legacy aliases of versioned routes.
+type Operation struct {
+	Method     string
+	Path       string
+	Summary    string
+	Tags       []string
+	Params     interface{}
+	Response   interface{}
+	Status     int
+	Deprecated bool
+	Query      map[string]string
+}
+
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type PathItem struct {
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	OperationID string               `json:"operationId"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+}
+
+var pathParamRX = regexp.MustCompile(`:(\w+)`)
+
+// Generate builds the document. problem is the type of error responses.
+func Generate(title, version string, problem interface{}, ops []Operation) *Document {
+
+	d := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       Info{Title: title, Version: version},
+		Paths:      make(map[string]map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+
+	problemSchema := d.schema(reflect.TypeOf(problem))
+
+	for _, op := range ops {
+		path := OpenAPIPath(op.Path)
+		if d.Paths[path] == nil {
+			d.Paths[path] = make(map[string]*PathItem)
+		}
+
+		item := &PathItem{
+			Summary:     op.Summary,
+			Tags:        op.Tags,
+			OperationID: operationID(op.Method, path),
+			Deprecated:  op.Deprecated,
+			Responses:   make(map[string]*Response),
+		}
+
+		if op.Params != nil {
+			d.parameters(item, reflect.TypeOf(op.Params), op.Query)
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		resp := &Response{Description: http.StatusText(status)}
+		if op.Response != nil {
+			resp.Content = map[string]*MediaType{"application/json": {Schema: d.schema(reflect.TypeOf(op.Response))}}
+		}
+		item.Responses[strconv.Itoa(status)] = resp
+		item.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     map[string]*MediaType{"application/problem+json": {Schema: problemSchema}},
+		}
+
+		d.Paths[path][strings.ToLower(op.Method)] = item
+	}
+	return d
+}
+
+// OpenAPIPath converts httprouter path parameters to OpenAPI templates.
+func OpenAPIPath(path string) string {
+	return pathParamRX.ReplaceAllString(path, "{$1}")
+}
+
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.Split(path, "/") {
+		part = strings.Trim(part, "{}")
+		if part == "" {
+			continue
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+func (d *Document) parameters(item *PathItem, t reflect.Type, query map[string]string) {
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			d.parameters(item, f.Type, query)
+			continue
+		}
+
+		if _, ok := f.Tag.Lookup("body"); ok {
+			item.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{"application/json": {Schema: d.schema(f.Type)}},
+			}
+			continue
+		}
+
+		in, name := "query", f.Tag.Get("query")
+		required := f.Tag.Get("required") == "true"
+		if n, ok := f.Tag.Lookup("path"); ok {
+			in, name, required = "path", n, true
+			if q, ok := query[n]; ok {
+				in, name = "query", q
+			}
+		}
+		if name == "" {
+			continue
+		}
+
+		s := d.schema(f.Type)
+		if v, ok := f.Tag.Lookup("min"); ok {
+			m, _ := strconv.ParseFloat(v, 64)
+			s.Minimum = &m
+		}
+		if v, ok := f.Tag.Lookup("max"); ok {
+			m, _ := strconv.ParseFloat(v, 64)
+			s.Maximum = &m
+		}
+		if v, ok := f.Tag.Lookup("enum"); ok {
+			for _, e := range strings.Split(v, ",") {
+				s.Enum = append(s.Enum, e)
+			}
+		}
+		if v, ok := f.Tag.Lookup("default"); ok {
+			s.Default = typedDefault(s.Type, v)
+		}
+
+		item.Parameters = append(item.Parameters, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: required,
+			Schema:   s,
+		})
+	}
+}
+
+func typedDefault(typ, v string) interface{} {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of t. Named structs are added to the
+// components and referenced.
+func (d *Document) schema(t reflect.Type) *Schema {
+
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := schemaName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// reserve the name first so recursive types terminate
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case t.Kind() == reflect.Struct:
+		return d.object(t)
+	}
+
+	s := &Schema{Nullable: nullable}
+	switch t.Kind() {
+	case reflect.Bool:
+		s.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		s.Type, s.Format = "integer", "int32"
+	case reflect.Int64, reflect.Uint64:
+		s.Type, s.Format = "integer", "int64"
+	case reflect.Float32, reflect.Float64:
+		s.Type, s.Format = "number", "double"
+	case reflect.String:
+		s.Type = "string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			s.Type, s.Format = "string", "byte"
+		} else {
+			s.Type, s.Items = "array", d.schema(t.Elem())
+			s.Nullable = t.Kind() == reflect.Slice
+		}
+	case reflect.Map:
+		s.Type, s.AdditionalProperties = "object", d.schema(t.Elem())
+	default:
+		// interface{} and friends accept anything
+	}
+	return s
+}
+
+func (d *Document) object(t reflect.Type) *Schema {
+
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.fields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+// fields adds the json encoded fields of t to s following the rules of
+// encoding/json for names, omitempty and embedded structs.
+func (d *Document) fields(s *Schema, t reflect.Type) {
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.fields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = d.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name := t.Name()
+	// generic instantiations contain brackets
+	name = strings.NewReplacer("[", "_", "]", "", "*", "", ".", "_", "/", "_").Replace(name)
+	if pkg == "" || pkg == "main" {
+		return strings.ToUpper(name[:1]) + name[1:]
+	}
+	return fmt.Sprintf("%s.%s", pkg, name)
+}
@@ -0,0 +1,62 @@
+"use strict";
+
+// resolves $refs so schemas can be shown inline
+function resolve(spec, schema, depth) {
+  if (!schema || depth > 6) return schema;
+  if (schema.$ref) {
+    const name = schema.$ref.replace("#/components/schemas/", "");
+    return resolve(spec, spec.components.schemas[name], depth + 1);
+  }
+  const out = Object.assign({}, schema);
+  if (out.properties) {
+    out.properties = Object.fromEntries(Object.entries(out.properties)
+      .map(([k, v]) => [k, resolve(spec, v, depth + 1)]));
+  }
+  if (out.items) out.items = resolve(spec, out.items, depth + 1);
+  if (out.additionalProperties) out.additionalProperties = resolve(spec, out.additionalProperties, depth + 1);
+  return out;
+}
+
+function el(tag, attrs, ...children) {
+  const e = document.createElement(tag);
+  Object.entries(attrs || {}).forEach(([k, v]) => e.setAttribute(k, v));
+  children.forEach(c => e.append(c));
+  return e;
+}
+
+function parameters(op) {
+  const table = el("table", {}, el("tr", {}, el("th", {}, "name"), el("th", {}, "in"), el("th", {}, "type"), el("th", {}, "required"), el("th", {}, "constraints")));
+  (op.parameters || []).forEach(p => {
+    const c = [];
+    if (p.schema.minimum !== undefined) c.push("min " + p.schema.minimum);
+    if (p.schema.maximum !== undefined) c.push("max " + p.schema.maximum);
+    if (p.schema.enum) c.push("one of " + p.schema.enum.join(", "));
+    if (p.schema.default !== undefined) c.push("default " + p.schema.default);
+    table.append(el("tr", {}, el("td", {}, p.name), el("td", {}, p.in), el("td", {}, p.schema.type || ""), el("td", {}, p.required ? "yes" : "no"), el("td", {}, c.join(", "))));
+  });
+  return table;
+}
+
+fetch("/openapi.json").then(r => r.json()).then(spec => {
+  document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+  const main = document.getElementById("operations");
+  Object.keys(spec.paths).sort().forEach(path => {
+    Object.entries(spec.paths[path]).forEach(([method, op]) => {
+      const body = el("div", {}, el("p", {}, op.summary || ""));
+      if (op.parameters) body.append(el("h4", {}, "Parameters"), parameters(op));
+      if (op.requestBody) {
+        const s = resolve(spec, op.requestBody.content["application/json"].schema, 0);
+        body.append(el("h4", {}, "Request body"), el("pre", {}, JSON.stringify(s, null, 2)));
+      }
+      Object.entries(op.responses).forEach(([status, resp]) => {
+        body.append(el("h4", {}, "Response " + status));
+        Object.values(resp.content || {}).forEach(mt => {
+          body.append(el("pre", {}, JSON.stringify(resolve(spec, mt.schema, 0), null, 2)));
+        });
+      });
+      const d = el("details", op.deprecated ? { class: "deprecated" } : {},
+        el("summary", {}, el("span", { class: "method " + method }, method), path), body);
+      main.append(d);
+    });
+  });
+});
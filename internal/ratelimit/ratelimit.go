@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Limit configures a token bucket. Rate tokens are added per second up to
+// Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit parses a limit written as "rate,burst", e.g. "2,60" for 2
+// tokens per second up to 60.
+func ParseLimit(s string) (Limit, error) {
+
+	rate, burst, ok := strings.Cut(s, ",")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q: want rate,burst", s)
+	}
+	var l Limit
+	var err error
+	if l.Rate, err = strconv.ParseFloat(strings.TrimSpace(rate), 64); err != nil || l.Rate <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: rate must be a positive number", s)
+	}
+	if l.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil || l.Burst <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: burst must be a positive integer", s)
+	}
+	return l, nil
+}
+
+// Result of taking tokens from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// time until the bucket is full again
+	Reset time.Duration
+	// time until the request would be allowed, zero if it is allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Take removes cost tokens from the bucket of key
+// if enough are left.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, cost int) (Result, error)
+}
+
+func result(limit Limit, tokens float64, cost int, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second)),
+	}
+	if !allowed {
+		r.RetryAfter = time.Duration((float64(cost) - tokens) / limit.Rate * float64(time.Second))
+	}
+	return r
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// time the bucket takes to fill up under the limit it was last taken
+	// from
+	fill time.Duration
+}
+
+// fill returns the time a bucket of limit takes to fill up from empty.
+func fill(limit Limit) time.Duration {
+	return time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+}
+
+// MemoryStore keeps buckets in process. Use it when a single instance
+// serves the api.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), swept: time.Now()}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+	b.fill = fill(limit)
+
+	allowed := b.tokens >= float64(cost)
+	if allowed {
+		b.tokens -= float64(cost)
+	}
+	return result(limit, b.tokens, cost, allowed), nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+// under their own limit
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	for k, b := range s.buckets {
+		if now.Sub(b.updated) > b.fill {
+			delete(s.buckets, k)
+		}
+	}
+	s.swept = now
+}
+
+const (
+	takeTokens = `INSERT INTO rate_limits (key, tokens, allowed, updated)
+					VALUES ($1, CASE WHEN $2::float8 >= $3::float8 THEN $2::float8 - $3::float8 ELSE $2::float8 END, $2::float8 >= $3::float8, CURRENT_TIMESTAMP)
+					ON CONFLICT (key) DO UPDATE SET
+					tokens = CASE
+						WHEN LEAST($2::float8, rate_limits.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - rate_limits.updated) * $4::float8) >= $3::float8
+						THEN LEAST($2::float8, rate_limits.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - rate_limits.updated) * $4::float8) - $3::float8
+						ELSE LEAST($2::float8, rate_limits.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - rate_limits.updated) * $4::float8)
+					END,
+					allowed = LEAST($2::float8, rate_limits.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - rate_limits.updated) * $4::float8) >= $3::float8,
+					updated = CURRENT_TIMESTAMP
+					RETURNING tokens, allowed`
+	deleteIdle = `DELETE FROM rate_limits WHERE updated < CURRENT_TIMESTAMP - $1::interval`
+)
+
+// PostgresStore shares buckets between several instances. Every Take is a
+// single upsert, so concurrent requests can't overdraw a bucket.
+type PostgresStore struct {
+	Pool *pgxpool.Pool
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+
+	var tokens float64
+	var allowed bool
+	err := s.Pool.QueryRow(ctx, takeTokens, key, limit.Burst, cost, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, tokens, cost, allowed), nil
+}
+
+// Sweep deletes the buckets that have not been used for idle. Buckets idle
+// for longer than it takes to fill them are full again, deleting them
+// changes nothing.
+func (s *PostgresStore) Sweep(ctx context.Context, idle time.Duration) (int64, error) {
+	tag, err := s.Pool.Exec(ctx, deleteIdle, idle)
+	return tag.RowsAffected(), err
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSweep(t *testing.T) {
+
+	// ip buckets fill up in 30s, user buckets in 24s
+	ip := Limit{Rate: 2, Burst: 60}
+	user := Limit{Rate: 5, Burst: 120}
+
+	tests := []struct {
+		name string
+		idle time.Duration
+		ip   bool
+		user bool
+	}{
+		{"neither is full", 10 * time.Second, true, true},
+		{"user is full", 27 * time.Second, true, false},
+		{"both are full", 31 * time.Second, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore()
+			for key, limit := range map[string]Limit{"ip": ip, "user": user} {
+				if _, err := s.Take(context.Background(), key, limit, 10); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the sweep runs at most once a minute
+			s.swept = time.Time{}
+			s.sweep(time.Now().Add(tt.idle))
+
+			if _, ok := s.buckets["ip"]; ok != tt.ip {
+				t.Errorf("ip bucket kept = %v, want %v", ok, tt.ip)
+			}
+			if _, ok := s.buckets["user"]; ok != tt.user {
+				t.Errorf("user bucket kept = %v, want %v", ok, tt.user)
+			}
+		})
+	}
+}
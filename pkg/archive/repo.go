@@ -0,0 +1,39 @@
+// Package archive keeps every rapid api response verbatim so the normalised
+// tables can be rebuilt without downloading a season again.
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/url"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// a response identical to an archived one only refreshes fetched_at
+	insertResponse = `INSERT INTO raw_responses (endpoint, params, body, hash) VALUES ($1, $2, $3, $4)
+						ON CONFLICT (endpoint, params, hash) DO UPDATE SET fetched_at = now()`
+	selectLatestResponse = `SELECT body FROM raw_responses WHERE endpoint = $1 AND params = $2 ORDER BY fetched_at DESC LIMIT 1`
+)
+
+// Repo implements comm.ResponseStore on the raw_responses table.
+type Repo struct {
+	Pool *pgxpool.Pool
+}
+
+func (repo *Repo) Store(ctx context.Context, endpoint string, params url.Values, body []byte) error {
+
+	hash := sha256.Sum256(body)
+	// body is passed as string so postgres parses it as json
+	_, err := repo.Pool.Exec(ctx, insertResponse, endpoint, params, string(body), hash[:])
+	return err
+}
+
+// Load returns the latest archived body of endpoint called with params.
+func (repo *Repo) Load(ctx context.Context, endpoint string, params url.Values) ([]byte, error) {
+
+	var body string
+	err := repo.Pool.QueryRow(ctx, selectLatestResponse, endpoint, params).Scan(&body)
+	return []byte(body), err
+}
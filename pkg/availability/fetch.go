@@ -0,0 +1,62 @@
+package availability
+
+import (
+	"encoding/json"
+
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/bernhardson/prefoot/pkg/team"
+)
+
+const (
+	injuriesURL = "https://api-football-v1.p.rapidapi.com/v3/injuries?league=%d&season=%d"
+)
+
+type InjuriesResponse struct {
+	Get        string        `json:"get"`
+	Parameters interface{}   `json:"parameters"`
+	Errors     interface{}   `json:"errors"`
+	Results    int           `json:"results"`
+	Paging     shared.Paging `json:"paging"`
+	Response   []Injury      `json:"response"`
+}
+
+type Injury struct {
+	Player  InjuredPlayer `json:"player"`
+	Team    team.Team     `json:"team"`
+	Fixture struct {
+		ID        int    `json:"id"`
+		Timezone  string `json:"timezone"`
+		Date      string `json:"date"`
+		Timestamp int    `json:"timestamp"`
+	} `json:"fixture"`
+	League struct {
+		ID     int `json:"id"`
+		Season int `json:"season"`
+	} `json:"league"`
+}
+
+// InjuredPlayer is the player of an injury. Type is "Missing Fixture" or
+// "Questionable", reason e.g. "Knee Injury" or "Red Card".
+type InjuredPlayer struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Photo  string `json:"photo"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func GetInjuries(c *comm.Client, league, season int) (*InjuriesResponse, error) {
+
+	data, err := c.GetHttpBody(injuriesURL, league, season)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &InjuriesResponse{}
+	err = json.Unmarshal(data, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,149 @@
+package availability
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	upsertInjury = `INSERT INTO injuries (player, fixture, team, league, season, name, type, reason) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+						ON CONFLICT (player, fixture) DO UPDATE SET team = EXCLUDED.team, name = EXCLUDED.name,
+						type = EXCLUDED.type, reason = EXCLUDED.reason`
+	selectInjuriesByFixture = `SELECT player, fixture, team, league, season, name, type, reason FROM injuries WHERE fixture = $1`
+	selectRule              = `SELECT league, yellow_limits, yellow_bans, red_ban FROM suspension_rules WHERE league = $1`
+	// yellow and red cards of a league season in the order they were shown
+	selectCards = `SELECT ps.player, ps.team, ps.fixture, f.timestamp, ps.yellow, ps.red
+					FROM player_statistics ps JOIN fixtures f ON f.id = ps.fixture
+					WHERE f.league = $1 AND f.season = $2 AND (ps.yellow > 0 OR ps.red > 0)
+					ORDER BY f.timestamp, ps.fixture, ps.player`
+	selectSchedule = `SELECT id AS fixture, home_team, away_team, timestamp FROM fixtures
+						WHERE league = $1 AND season = $2 ORDER BY timestamp, id`
+	selectNames = `SELECT id, name FROM people WHERE id = ANY($1)`
+)
+
+type Repo struct {
+	Pool *pgxpool.Pool
+}
+
+type InjuryRow struct {
+	Player  int    `json:"player"`
+	Fixture int    `json:"fixture"`
+	Team    int    `json:"team"`
+	League  int    `json:"league"`
+	Season  int    `json:"season"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+}
+
+// CardRow are the cards a player got in a fixture.
+type CardRow struct {
+	Player    int
+	Team      int
+	Fixture   int
+	Timestamp int
+	Yellow    int
+	Red       int
+}
+
+// ScheduleRow is a fixture of a league season.
+type ScheduleRow struct {
+	Fixture   int
+	HomeTeam  int
+	AwayTeam  int
+	Timestamp int
+}
+
+// UpsertInjuries writes injuries in one transaction, known ones are
+// updated.
+func (repo *Repo) UpsertInjuries(rows []*InjuryRow) (int64, error) {
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	ctx := context.Background()
+	tx, err := repo.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	b := &pgx.Batch{}
+	for _, i := range rows {
+		b.Queue(upsertInjury, i.Player, i.Fixture, i.Team, i.League, i.Season, i.Name, i.Type, i.Reason)
+	}
+	res := tx.SendBatch(ctx, b)
+	var n int64
+	for range rows {
+		tag, err := res.Exec()
+		if err != nil {
+			res.Close()
+			return 0, err
+		}
+		n += tag.RowsAffected()
+	}
+	if err := res.Close(); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit(ctx)
+}
+
+func (repo *Repo) SelectInjuriesByFixture(fixture int) ([]*InjuryRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectInjuriesByFixture, fixture)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[InjuryRow])
+}
+
+// SelectRule returns the suspension rule of a league, pgx.ErrNoRows if the
+// league has none.
+func (repo *Repo) SelectRule(league int) (*Rule, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectRule, league)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[Rule])
+}
+
+func (repo *Repo) SelectCards(league, season int) ([]*CardRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectCards, league, season)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[CardRow])
+}
+
+func (repo *Repo) SelectSchedule(league, season int) ([]*ScheduleRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectSchedule, league, season)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[ScheduleRow])
+}
+
+// SelectNames returns the names of players by id.
+func (repo *Repo) SelectNames(ids []int) (map[int]string, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectNames, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int]string, len(ids))
+	var id int
+	var name string
+	_, err = pgx.ForEachRow(rows, []any{&id, &name}, func() error {
+		names[id] = name
+		return nil
+	})
+	return names, err
+}
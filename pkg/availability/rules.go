@@ -0,0 +1,87 @@
+package availability
+
+import "fmt"
+
+// Rule describes how a league suspends players for cards. Reaching
+// YellowLimits[i] yellow cards bans for YellowBans[i] fixtures, a red card
+// for RedBan fixtures.
+type Rule struct {
+	League       int   `json:"league"`
+	YellowLimits []int `json:"yellow_limits"`
+	YellowBans   []int `json:"yellow_bans"`
+	RedBan       int   `json:"red_ban"`
+}
+
+// DefaultRule applies to leagues without a rule of their own.
+var DefaultRule = Rule{
+	YellowLimits: []int{5, 10, 15},
+	YellowBans:   []int{1, 1, 1},
+	RedBan:       1,
+}
+
+// Suspension is a ban following the cards of a fixture, Fixtures are the
+// team's fixtures the player misses.
+type Suspension struct {
+	Player   int    `json:"player"`
+	Team     int    `json:"team"`
+	Fixture  int    `json:"fixture"`
+	Reason   string `json:"reason"`
+	Fixtures []int  `json:"fixtures"`
+}
+
+// Suspensions derives the suspensions of a league season from its cards,
+// ordered by time, and its schedule. Yellow cards count per player over
+// the season, yellows of a fixture with a red card are part of the
+// sending off and do not count. A ban covers the next fixtures of the team
+// the player got the cards for.
+func Suspensions(rule *Rule, cards []*CardRow, schedule []*ScheduleRow) []*Suspension {
+
+	type teamFixture struct{ team, fixture int }
+	fixtures := make(map[int][]int)
+	pos := make(map[teamFixture]int)
+	for _, s := range schedule {
+		for _, t := range []int{s.HomeTeam, s.AwayTeam} {
+			pos[teamFixture{t, s.Fixture}] = len(fixtures[t])
+			fixtures[t] = append(fixtures[t], s.Fixture)
+		}
+	}
+
+	var suspensions []*Suspension
+	yellows := make(map[int]int)
+	for _, c := range cards {
+		i, ok := pos[teamFixture{c.Team, c.Fixture}]
+		if !ok {
+			continue
+		}
+
+		ban, reason := 0, ""
+		if c.Red > 0 {
+			ban, reason = rule.RedBan, "red card"
+		} else {
+			before := yellows[c.Player]
+			yellows[c.Player] += c.Yellow
+			for j, limit := range rule.YellowLimits {
+				if j < len(rule.YellowBans) && before < limit && yellows[c.Player] >= limit && rule.YellowBans[j] > ban {
+					ban, reason = rule.YellowBans[j], fmt.Sprintf("%d yellow cards", limit)
+				}
+			}
+		}
+		if ban <= 0 {
+			continue
+		}
+
+		team := fixtures[c.Team]
+		end := i + 1 + ban
+		if end > len(team) {
+			end = len(team)
+		}
+		suspensions = append(suspensions, &Suspension{
+			Player:   c.Player,
+			Team:     c.Team,
+			Fixture:  c.Fixture,
+			Reason:   reason,
+			Fixtures: append([]int(nil), team[i+1:end]...),
+		})
+	}
+	return suspensions
+}
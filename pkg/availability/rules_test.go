@@ -0,0 +1,155 @@
+package availability
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuspensions(t *testing.T) {
+
+	// team 1 plays fixtures 1 to 6, team 2 the first three of them
+	schedule := []*ScheduleRow{
+		{Fixture: 1, HomeTeam: 1, AwayTeam: 2},
+		{Fixture: 2, HomeTeam: 2, AwayTeam: 1},
+		{Fixture: 3, HomeTeam: 1, AwayTeam: 2},
+		{Fixture: 4, HomeTeam: 1, AwayTeam: 3},
+		{Fixture: 5, HomeTeam: 3, AwayTeam: 1},
+		{Fixture: 6, HomeTeam: 1, AwayTeam: 3},
+	}
+	rule := &Rule{YellowLimits: []int{2, 4}, YellowBans: []int{1, 2}, RedBan: 3}
+
+	tests := []struct {
+		name  string
+		rule  *Rule
+		cards []*CardRow
+		want  []*Suspension
+	}{
+		{
+			name:  "no cards",
+			rule:  rule,
+			cards: nil,
+		},
+		{
+			name: "below the yellow limit",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 1},
+			},
+		},
+		{
+			name: "reaching the yellow limit",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 1},
+				{Player: 7, Team: 1, Fixture: 2, Yellow: 1},
+			},
+			want: []*Suspension{
+				{Player: 7, Team: 1, Fixture: 2, Reason: "2 yellow cards", Fixtures: []int{3}},
+			},
+		},
+		{
+			name: "each limit bans once",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 2},
+				{Player: 7, Team: 1, Fixture: 2, Yellow: 1},
+				{Player: 7, Team: 1, Fixture: 3, Yellow: 1},
+			},
+			want: []*Suspension{
+				{Player: 7, Team: 1, Fixture: 1, Reason: "2 yellow cards", Fixtures: []int{2}},
+				{Player: 7, Team: 1, Fixture: 3, Reason: "4 yellow cards", Fixtures: []int{4, 5}},
+			},
+		},
+		{
+			name: "passing two limits at once takes the longer ban",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 1},
+				{Player: 7, Team: 1, Fixture: 2, Yellow: 3},
+			},
+			want: []*Suspension{
+				{Player: 7, Team: 1, Fixture: 2, Reason: "4 yellow cards", Fixtures: []int{3, 4}},
+			},
+		},
+		{
+			name: "yellows of a sending off don't count",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 2, Red: 1},
+				{Player: 7, Team: 1, Fixture: 5, Yellow: 1},
+			},
+			want: []*Suspension{
+				{Player: 7, Team: 1, Fixture: 1, Reason: "red card", Fixtures: []int{2, 3, 4}},
+			},
+		},
+		{
+			name: "yellows count per player",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 1},
+				{Player: 8, Team: 1, Fixture: 2, Yellow: 1},
+			},
+		},
+		{
+			name: "ban is cut at the end of the schedule",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 9, Team: 2, Fixture: 2, Red: 1},
+				{Player: 7, Team: 1, Fixture: 6, Red: 1},
+			},
+			want: []*Suspension{
+				{Player: 9, Team: 2, Fixture: 2, Reason: "red card", Fixtures: []int{3}},
+				{Player: 7, Team: 1, Fixture: 6, Reason: "red card"},
+			},
+		},
+		{
+			name: "cards of fixtures outside the schedule are ignored",
+			rule: rule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 99, Red: 1},
+				{Player: 7, Team: 2, Fixture: 4, Red: 1},
+			},
+		},
+		{
+			name: "limits without a ban are ignored",
+			rule: &Rule{YellowLimits: []int{1, 2}, YellowBans: []int{1}},
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 1},
+				{Player: 7, Team: 1, Fixture: 2, Yellow: 1},
+				{Player: 8, Team: 1, Fixture: 3, Red: 1},
+			},
+			want: []*Suspension{
+				{Player: 7, Team: 1, Fixture: 1, Reason: "1 yellow cards", Fixtures: []int{2}},
+			},
+		},
+		{
+			name: "default rule",
+			rule: &DefaultRule,
+			cards: []*CardRow{
+				{Player: 7, Team: 1, Fixture: 1, Yellow: 4},
+				{Player: 7, Team: 1, Fixture: 2, Yellow: 1},
+			},
+			want: []*Suspension{
+				{Player: 7, Team: 1, Fixture: 2, Reason: "5 yellow cards", Fixtures: []int{3}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Suspensions(tt.rule, tt.cards, schedule)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suspensions() = %+v, want %+v", format(got), format(tt.want))
+			}
+		})
+	}
+}
+
+// format dereferences ss to print the suspensions instead of pointers.
+func format(ss []*Suspension) []Suspension {
+	out := make([]Suspension, 0, len(ss))
+	for _, s := range ss {
+		out = append(out, *s)
+	}
+	return out
+}
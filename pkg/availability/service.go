@@ -0,0 +1,157 @@
+package availability
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// statuses of absences
+const (
+	Out      = "out"
+	Doubtful = "doubtful"
+)
+
+type Model struct {
+	Logger *zerolog.Logger
+	Client *comm.Client
+	Repo   interface {
+		UpsertInjuries([]*InjuryRow) (int64, error)
+		SelectInjuriesByFixture(int) ([]*InjuryRow, error)
+		SelectRule(int) (*Rule, error)
+		SelectCards(int, int) ([]*CardRow, error)
+		SelectSchedule(int, int) ([]*ScheduleRow, error)
+		SelectNames([]int) (map[int]string, error)
+	}
+}
+
+// Absence is a player expected to miss or doubtful for a fixture.
+type Absence struct {
+	Player int    `json:"player"`
+	Name   string `json:"name"`
+	Team   int    `json:"team"`
+	Status string `json:"status"`
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+}
+
+// FetchAndInsertInjuries downloads the injuries of a league season.
+func (m *Model) FetchAndInsertInjuries(league, season int) (int64, error) {
+
+	resp, err := GetInjuries(m.Client, league, season)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([]*InjuryRow, 0, len(resp.Response))
+	for _, i := range resp.Response {
+		rows = append(rows, &InjuryRow{
+			Player:  i.Player.ID,
+			Fixture: i.Fixture.ID,
+			Team:    i.Team.ID,
+			League:  league,
+			Season:  season,
+			Name:    i.Player.Name,
+			Type:    i.Player.Type,
+			Reason:  i.Player.Reason,
+		})
+	}
+
+	n, err := m.Repo.UpsertInjuries(rows)
+	if err != nil {
+		return 0, err
+	}
+	m.Logger.Info().Msg(fmt.Sprintf("ingest injuries: league=%d#season=%d#rows=%d", league, season, n))
+	return n, nil
+}
+
+// Rule returns the suspension rule of a league or the default rule.
+func (m *Model) Rule(league int) (*Rule, error) {
+
+	r, err := m.Repo.SelectRule(league)
+	if errors.Is(err, pgx.ErrNoRows) {
+		def := DefaultRule
+		def.League = league
+		return &def, nil
+	}
+	return r, err
+}
+
+// Absences lists the players of home and away expected to miss or doubtful
+// for a fixture of a league season, injured as reported by the provider
+// or suspended for cards.
+func (m *Model) Absences(fixture, league, season, home, away int) ([]*Absence, error) {
+
+	injuries, err := m.Repo.SelectInjuriesByFixture(fixture)
+	if err != nil {
+		return nil, err
+	}
+
+	absences := make([]*Absence, 0, len(injuries))
+	seen := make(map[int]bool)
+	for _, i := range injuries {
+		status := Out
+		if i.Type == "Questionable" {
+			status = Doubtful
+		}
+		// the provider lists suspensions it knows of as injuries
+		kind := "injury"
+		if strings.Contains(i.Reason, "Card") || strings.Contains(i.Reason, "Suspended") {
+			kind = "suspension"
+		}
+		seen[i.Player] = true
+		absences = append(absences, &Absence{
+			Player: i.Player, Name: i.Name, Team: i.Team, Status: status, Kind: kind, Reason: i.Reason,
+		})
+	}
+
+	rule, err := m.Rule(league)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := m.Repo.SelectCards(league, season)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := m.Repo.SelectSchedule(league, season)
+	if err != nil {
+		return nil, err
+	}
+
+	var suspended []*Absence
+	var ids []int
+	for _, s := range Suspensions(rule, cards, schedule) {
+		if (s.Team != home && s.Team != away) || seen[s.Player] || !contains(s.Fixtures, fixture) {
+			continue
+		}
+		seen[s.Player] = true
+		ids = append(ids, s.Player)
+		suspended = append(suspended, &Absence{
+			Player: s.Player, Team: s.Team, Status: Out, Kind: "suspension", Reason: s.Reason,
+		})
+	}
+	if len(suspended) > 0 {
+		names, err := m.Repo.SelectNames(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range suspended {
+			a.Name = names[a.Player]
+		}
+	}
+
+	return append(absences, suspended...), nil
+}
+
+func contains(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
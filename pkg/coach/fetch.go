@@ -42,9 +42,9 @@ type Career []struct {
 	End   string    `json:"end"`
 }
 
-func GetCoach(id int) (*CoachResponse, error) {
+func GetCoach(c *comm.Client, id int) (*CoachResponse, error) {
 
-	data, err := comm.GetHttpBody(coachURL, id)
+	data, err := c.GetHttpBody(coachURL, id)
 	if err != nil {
 		return nil, err
 	}
@@ -4,12 +4,38 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	insertCoach       = `INSERT INTO coaches (id, name) VALUES ($1, $2)`
-	insertCoachCareer = `INSERT INTO coach_careers (coach, team, start, "end") VALUES ($1, $2, $3, $4)`
+	insertCoach = `INSERT INTO coaches (id, name, firstname, lastname, birthdate, nationality, photo, team) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+					ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, firstname = EXCLUDED.firstname, lastname = EXCLUDED.lastname,
+					birthdate = EXCLUDED.birthdate, nationality = EXCLUDED.nationality, photo = EXCLUDED.photo, team = EXCLUDED.team`
+	insertCoachCareer = `INSERT INTO coach_careers (coach, team, team_name, start, "end") VALUES ($1, $2, $3, $4, $5)
+							ON CONFLICT (coach, team, start) DO UPDATE SET team_name = EXCLUDED.team_name, "end" = EXCLUDED."end"`
+	selectCoach = `SELECT id, coalesce(name, '') AS name, firstname, lastname, birthdate, nationality, photo, team
+					FROM coaches WHERE id = $1`
+	selectCareer = `SELECT coach, team, team_name, start, "end" FROM coach_careers WHERE coach = $1 ORDER BY start`
+	// the job at the team that started last before start
+	selectPredecessor = `SELECT coach, team, team_name, start, "end" FROM coach_careers WHERE team = $1 AND start < $2 ORDER BY start DESC LIMIT 1`
+
+	// played matches of a team, a fixture counts as played once the team
+	// has a result for its round
+	selectMatches = `SELECT f.id AS fixture, f.timestamp,
+						CASE WHEN f.home_team = $1 THEN f.home_goals ELSE f.away_goals END AS goals_for,
+						CASE WHEN f.home_team = $1 THEN f.away_goals ELSE f.home_goals END AS goals_against,
+						coalesce(fo.formation, '') AS formation
+					FROM fixtures f
+					LEFT JOIN formations fo ON fo.fixture = f.id AND fo.team = $1
+					WHERE (f.home_team = $1 OR f.away_team = $1)
+						AND EXISTS (SELECT 1 FROM results r WHERE r.team = $1 AND r.league = f.league
+							AND r.season = f.season AND r.round = f.round AND r.elapsed > 0)`
+	selectMatchesBetween = selectMatches + ` AND f.timestamp >= $2 AND ($3::bigint IS NULL OR f.timestamp < $3)
+							ORDER BY f.timestamp LIMIT $4`
+	selectMatchesBefore = `SELECT * FROM (` + selectMatches + ` AND f.timestamp < $2 AND ($4::bigint IS NULL OR f.timestamp >= $4)
+							ORDER BY f.timestamp DESC LIMIT $3) m
+							ORDER BY timestamp`
 )
 
 type CoachRepo struct {
@@ -18,33 +44,123 @@ type CoachRepo struct {
 
 // Coach represents the coaches table
 type CoachRow struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	FirstName   string `json:"firstname"`
+	LastName    string `json:"lastname"`
+	BirthDate   string `json:"birthdate"`
+	Nationality string `json:"nationality"`
+	Photo       string `json:"photo"`
+	Team        *int   `json:"team"`
 }
 
+// Insert writes a coach or updates the profile of a known one.
 func (cm *CoachRepo) Insert(c *CoachRow) (int64, error) {
 	row, err := cm.Pool.Exec(
 		context.Background(),
 		insertCoach,
-		c.ID, c.Name,
+		c.ID, c.Name, c.FirstName, c.LastName, c.BirthDate, c.Nationality, c.Photo, c.Team,
 	)
 	return row.RowsAffected(), err
 }
 
-// CoachCareer represents the coach_careers table
+func (cm *CoachRepo) Select(id int) (*CoachRow, error) {
+
+	rows, err := cm.Pool.Query(context.Background(), selectCoach, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[CoachRow])
+}
+
+// CoachCareer represents the coach_careers table, End is nil for the
+// current job.
 type CoachCareerRow struct {
-	CoachID int        `json:"coach_id"`
-	TeamID  int        `json:"team_id"`
-	Start   *time.Time `json:"start"`
-	End     *time.Time `json:"end"`
+	CoachID  int        `json:"coach_id" db:"coach"`
+	TeamID   int        `json:"team_id" db:"team"`
+	TeamName string     `json:"team_name"`
+	Start    *time.Time `json:"start"`
+	End      *time.Time `json:"end"`
 }
 
+// InsertCareer writes a job of a coach, the end of a known job is updated.
 func (cm *CoachRepo) InsertCareer(c *CoachCareerRow) (int64, error) {
 	row, err := cm.Pool.Exec(
 		context.Background(),
 		insertCoachCareer,
-		c.CoachID, c.TeamID, c.Start, c.End,
+		c.CoachID, c.TeamID, c.TeamName, c.Start, c.End,
 	)
 
 	return row.RowsAffected(), err
 }
+
+// SelectCareer returns the jobs of a coach, earliest first.
+func (cm *CoachRepo) SelectCareer(id int) ([]*CoachCareerRow, error) {
+
+	rows, err := cm.Pool.Query(context.Background(), selectCareer, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[CoachCareerRow])
+}
+
+// SelectPredecessor returns the job of the coach of the team before start,
+// nil if there is none.
+func (cm *CoachRepo) SelectPredecessor(team int, start time.Time) (*CoachCareerRow, error) {
+
+	rows, err := cm.Pool.Query(context.Background(), selectPredecessor, team, start)
+	if err != nil {
+		return nil, err
+	}
+	job, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[CoachCareerRow])
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// MatchRow is a played match seen from one team.
+type MatchRow struct {
+	Fixture      int    `json:"fixture"`
+	Timestamp    int    `json:"timestamp"`
+	GoalsFor     int    `json:"goals_for"`
+	GoalsAgainst int    `json:"goals_against"`
+	Formation    string `json:"formation"`
+}
+
+// SelectMatches returns up to limit played matches of a team from from
+// until to, all matches since from if to is nil and all matches in that
+// time if limit is 0. Matches are ordered by time.
+func (cm *CoachRepo) SelectMatches(team int, from time.Time, to *time.Time, limit int) ([]*MatchRow, error) {
+
+	var until, max *int64
+	if to != nil {
+		u := to.Unix()
+		until = &u
+	}
+	if limit > 0 {
+		l := int64(limit)
+		max = &l
+	}
+	rows, err := cm.Pool.Query(context.Background(), selectMatchesBetween, team, from.Unix(), until, max)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[MatchRow])
+}
+
+// SelectMatchesBefore returns the last n played matches of a team before
+// before and, unless from is nil, not before from, ordered by time.
+func (cm *CoachRepo) SelectMatchesBefore(team int, from *time.Time, before time.Time, n int) ([]*MatchRow, error) {
+
+	var since *int64
+	if from != nil {
+		f := from.Unix()
+		since = &f
+	}
+	rows, err := cm.Pool.Query(context.Background(), selectMatchesBefore, team, before.Unix(), n, since)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[MatchRow])
+}
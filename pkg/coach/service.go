@@ -2,14 +2,17 @@ package coach
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/bernhardson/prefoot/pkg/comm"
 	"github.com/bernhardson/prefoot/pkg/team"
 	"github.com/rs/zerolog"
 )
 
 type CoachModel struct {
 	Logger *zerolog.Logger
+	Client *comm.Client
 	Repo   *CoachRepo
 }
 
@@ -19,15 +22,26 @@ func (cm *CoachModel) FetchAndInsertCoaches(teams *[]team.TeamVenue) (*[]int, *[
 
 	for _, t := range *teams {
 		//insert coach
-		cs, err := GetCoach(t.Team.ID)
+		cs, err := GetCoach(cm.Client, t.Team.ID)
 		if err != nil {
 			cm.Logger.Err(err).Msg(fmt.Sprintf("could not get for team id %d", t.Team.ID))
+			continue
 		}
 
 		for _, c := range cs.Response {
+			var current *int
+			if c.Team.ID != 0 {
+				current = &c.Team.ID
+			}
 			_, err = cm.Repo.Insert(&CoachRow{
-				ID:   c.ID,
-				Name: c.Name,
+				ID:          c.ID,
+				Name:        c.Name,
+				FirstName:   c.FirstName,
+				LastName:    c.LastName,
+				BirthDate:   c.Birth.Date,
+				Nationality: c.Nationality,
+				Photo:       c.Photo,
+				Team:        current,
 			})
 			if err != nil {
 				cm.Logger.Err(err).Msg(fmt.Sprintf("%d", c.ID))
@@ -38,21 +52,26 @@ func (cm *CoachModel) FetchAndInsertCoaches(teams *[]team.TeamVenue) (*[]int, *[
 				start, err := time.Parse("2006-01-02", cc.Start)
 				if err != nil {
 					cm.Logger.Err(err).Msg(fmt.Sprintf("coach_%d", c.ID))
+					fcc = append(fcc, c.ID)
+					continue
 				}
-				var end time.Time
+				// the current job has no end
+				var end *time.Time
 				if cc.End != "" {
-					end, err = time.Parse("2006-01-02", cc.End)
+					e, err := time.Parse("2006-01-02", cc.End)
 					if err != nil {
 						cm.Logger.Err(err).Msg(fmt.Sprintf("coach_%d", c.ID))
+						fcc = append(fcc, c.ID)
+						continue
 					}
-				} else {
-					end = time.Time{}
+					end = &e
 				}
 				_, err = cm.Repo.InsertCareer(&CoachCareerRow{
-					CoachID: c.ID,
-					TeamID:  cc.Team.ID,
-					Start:   &start,
-					End:     &end,
+					CoachID:  c.ID,
+					TeamID:   cc.Team.ID,
+					TeamName: cc.Team.Name,
+					Start:    &start,
+					End:      end,
 				})
 				if err != nil {
 					cm.Logger.Err(err).Msg(fmt.Sprintf("coach_%d#team%d", c.ID, cc.Team.ID))
@@ -65,3 +84,163 @@ func (cm *CoachModel) FetchAndInsertCoaches(teams *[]team.TeamVenue) (*[]int, *[
 	}
 	return &fc, &fcc, nil
 }
+
+// Performance summarises the played matches of a team.
+type Performance struct {
+	Matches      int              `json:"matches"`
+	Wins         int              `json:"wins"`
+	Draws        int              `json:"draws"`
+	Losses       int              `json:"losses"`
+	Points       int              `json:"points"`
+	PPG          float64          `json:"ppg"`
+	GoalsFor     int              `json:"goals_for"`
+	GoalsAgainst int              `json:"goals_against"`
+	Formations   []FormationCount `json:"formations"`
+}
+
+// FormationCount is how often a formation was used.
+type FormationCount struct {
+	Formation string `json:"formation"`
+	Matches   int    `json:"matches"`
+}
+
+// Summarise computes the performance over matches, formations are sorted
+// by use.
+func Summarise(matches []*MatchRow) *Performance {
+
+	p := &Performance{Formations: []FormationCount{}}
+	used := make(map[string]int)
+	for _, m := range matches {
+		p.Matches++
+		p.GoalsFor += m.GoalsFor
+		p.GoalsAgainst += m.GoalsAgainst
+		switch {
+		case m.GoalsFor > m.GoalsAgainst:
+			p.Wins++
+			p.Points += 3
+		case m.GoalsFor == m.GoalsAgainst:
+			p.Draws++
+			p.Points++
+		default:
+			p.Losses++
+		}
+		if m.Formation != "" {
+			used[m.Formation]++
+		}
+	}
+	if p.Matches > 0 {
+		p.PPG = float64(p.Points) / float64(p.Matches)
+	}
+
+	for f, n := range used {
+		p.Formations = append(p.Formations, FormationCount{Formation: f, Matches: n})
+	}
+	sort.Slice(p.Formations, func(i, j int) bool {
+		if p.Formations[i].Matches != p.Formations[j].Matches {
+			return p.Formations[i].Matches > p.Formations[j].Matches
+		}
+		return p.Formations[i].Formation < p.Formations[j].Formation
+	})
+	return p
+}
+
+// Tenure is a job of a coach with the team's performance during it.
+type Tenure struct {
+	CoachCareerRow
+	Performance *Performance `json:"performance"`
+}
+
+// Career returns the jobs of a coach, earliest first, with the performance
+// of the stored matches of each.
+func (cm *CoachModel) Career(id int) ([]*Tenure, error) {
+
+	jobs, err := cm.Repo.SelectCareer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenures := make([]*Tenure, 0, len(jobs))
+	for _, j := range jobs {
+		matches, err := cm.Repo.SelectMatches(j.TeamID, *j.Start, j.End, 0)
+		if err != nil {
+			return nil, err
+		}
+		tenures = append(tenures, &Tenure{CoachCareerRow: *j, Performance: Summarise(matches)})
+	}
+	return tenures, nil
+}
+
+// Bounce compares the first matches of a coach at a team with the last
+// matches of the predecessor, the new coach bounce. Matches between the
+// end of the predecessor's job and the start, e.g. under a caretaker, are
+// summarised in Caretaker and count on neither side.
+type Bounce struct {
+	Team        int          `json:"team"`
+	TeamName    string       `json:"team_name"`
+	Start       *time.Time   `json:"start"`
+	Predecessor *int         `json:"predecessor"`
+	Before      *Performance `json:"before"`
+	Caretaker   *Performance `json:"caretaker,omitempty"`
+	After       *Performance `json:"after"`
+	PPGChange   float64      `json:"ppg_change"`
+}
+
+// Bounces compares the first n matches of every job of a coach with the
+// last n matches of the predecessor's job at the team. Without a known
+// predecessor the team's last n matches before the start are compared.
+// Jobs without matches on either side are left out.
+func (cm *CoachModel) Bounces(id, n int) ([]*Bounce, error) {
+
+	jobs, err := cm.Repo.SelectCareer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	bounces := []*Bounce{}
+	for _, j := range jobs {
+		after, err := cm.Repo.SelectMatches(j.TeamID, *j.Start, j.End, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(after) == 0 {
+			continue
+		}
+		predecessor, err := cm.Repo.SelectPredecessor(j.TeamID, *j.Start)
+		if err != nil {
+			return nil, err
+		}
+
+		b := &Bounce{Team: j.TeamID, TeamName: j.TeamName, Start: j.Start, After: Summarise(after)}
+		var from *time.Time
+		until := *j.Start
+		if predecessor != nil {
+			b.Predecessor = &predecessor.CoachID
+			from = predecessor.Start
+			if predecessor.End != nil {
+				// the end is the last day in charge
+				if end := predecessor.End.AddDate(0, 0, 1); end.Before(until) {
+					caretaker, err := cm.Repo.SelectMatches(j.TeamID, end, j.Start, 0)
+					if err != nil {
+						return nil, err
+					}
+					if len(caretaker) > 0 {
+						b.Caretaker = Summarise(caretaker)
+					}
+					until = end
+				}
+			}
+		}
+
+		before, err := cm.Repo.SelectMatchesBefore(j.TeamID, from, until, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(before) == 0 {
+			continue
+		}
+		b.Before = Summarise(before)
+		b.PPGChange = b.After.PPG - b.Before.PPG
+		bounces = append(bounces, b)
+	}
+	return bounces, nil
+}
@@ -1,10 +1,15 @@
 package comm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog"
 )
 
 func AddRequestHeader(req *http.Request) *http.Request {
@@ -22,16 +27,69 @@ func UnmarshalData(body []byte) (map[string]interface{}, error) {
 	return data, nil
 }
 
-func GetHttpBody(url string, args ...interface{}) ([]byte, error) {
+// StatusError is returned for responses outside the 2xx range.
+type StatusError struct {
+	Code int
+	URL  string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s", e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// Fatal reports whether the error affects every further request, as for
+// exhausted quotas or a rejected api key.
+func (e *StatusError) Fatal() bool {
+	return e.Code == http.StatusTooManyRequests || e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+}
+
+// ResponseStore archives provider responses by endpoint and query params.
+type ResponseStore interface {
+	Store(ctx context.Context, endpoint string, params url.Values, body []byte) error
+	Load(ctx context.Context, endpoint string, params url.Values) ([]byte, error)
+}
+
+// Client fetches from the rapid api. The zero value calls the api and
+// archives nothing.
+type Client struct {
+	Logger *zerolog.Logger
+	// Recorder archives every fetched response when set.
+	Recorder ResponseStore
+	// Replay serves responses from its archive instead of calling the api
+	// when set, this is how the normalised tables are rebuilt.
+	Replay ResponseStore
+}
+
+func (c *Client) GetHttpBody(url string, args ...interface{}) ([]byte, error) {
+	return c.GetHttpBodyContext(context.Background(), url, args...)
+}
+
+// GetHttpBodyContext waits for a slot of the Provider limiter and fetches
+// url formatted with args. The request is aborted when ctx is done. A
+// response that can't be archived is logged and returned all the same.
+func (c *Client) GetHttpBodyContext(ctx context.Context, rawURL string, args ...interface{}) ([]byte, error) {
 
-	var err error
-	var req *http.Request
 	if args != nil {
-		req, err = http.NewRequest("GET", fmt.Sprintf(url, args...), nil)
-	} else {
-		req, err = http.NewRequest("GET", url, nil)
+		rawURL = fmt.Sprintf(rawURL, args...)
 	}
 
+	endpoint, params, err := splitURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if c.Replay != nil {
+		body, err := c.Replay.Load(ctx, endpoint, params)
+		if err != nil {
+			return nil, fmt.Errorf("replay %s %v: %w", endpoint, params, err)
+		}
+		return body, nil
+	}
+
+	if err := Provider.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -42,9 +100,30 @@ func GetHttpBody(url string, args ...interface{}) ([]byte, error) {
 		return nil, err
 	}
 	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &StatusError{Code: res.StatusCode, URL: rawURL}
+	}
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
+
+	if c.Recorder != nil {
+		if err := c.Recorder.Store(ctx, endpoint, params, body); err != nil && c.Logger != nil {
+			c.Logger.Err(err).Msg(fmt.Sprintf("archive %s %v", endpoint, params))
+		}
+	}
 	return body, nil
 }
+
+// splitURL returns the api path without version prefix and the query
+// params, which together identify an archived response. Repeated params
+// keep all their values.
+func splitURL(rawURL string) (string, url.Values, error) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.TrimPrefix(u.Path, "/v3"), u.Query(), nil
+}
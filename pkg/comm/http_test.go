@@ -0,0 +1,30 @@
+package comm
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSplitURL(t *testing.T) {
+
+	tests := []struct {
+		url      string
+		endpoint string
+		params   url.Values
+	}{
+		{"https://api-football-v1.p.rapidapi.com/v3/leagues", "/leagues", url.Values{}},
+		{"https://api-football-v1.p.rapidapi.com/v3/fixtures?league=39&season=2023", "/fixtures", url.Values{"league": {"39"}, "season": {"2023"}}},
+		{"https://api-football-v1.p.rapidapi.com/v3/players?id=1&id=2&season=2023", "/players", url.Values{"id": {"1", "2"}, "season": {"2023"}}},
+	}
+
+	for _, tt := range tests {
+		endpoint, params, err := splitURL(tt.url)
+		if err != nil {
+			t.Fatalf("splitURL(%q): %v", tt.url, err)
+		}
+		if endpoint != tt.endpoint || !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("splitURL(%q) = %q, %v, want %q, %v", tt.url, endpoint, params, tt.endpoint, tt.params)
+		}
+	}
+}
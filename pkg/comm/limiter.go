@@ -0,0 +1,59 @@
+package comm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// requests per second allowed by the rapid api plan
+const defaultRequestsPerSecond = 5
+
+// Provider spaces all requests to the rapid api, concurrent fetchers share
+// it so together they stay within the plan's quota.
+var Provider = NewLimiter(defaultRequestsPerSecond)
+
+// Limiter hands out request slots at a fixed rate.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func NewLimiter(perSecond float64) *Limiter {
+	return &Limiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// SetRate changes the number of requests per second.
+func (l *Limiter) SetRate(perSecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = time.Duration(float64(time.Second) / perSecond)
+}
+
+// Wait blocks until the next slot or until ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,54 @@
+package fixture
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	createTeamStatisticsStaging = `CREATE TEMP TABLE team_statistics_staging (LIKE team_statistics) ON COMMIT DROP`
+	mergeTeamStatistics         = `INSERT INTO team_statistics SELECT * FROM team_statistics_staging ON CONFLICT DO NOTHING`
+)
+
+// columns of team_statistics in the order of TeamStatisticsRow.values
+var teamStatisticsColumns = []string{
+	"team", "fixture", "shots_total", "shots_on", "shots_off", "shots_blocked",
+	"shots_box", "shots_outside", "offsides", "fouls", "corners", "possession", "yellow", "red",
+	"gk_saves", "passes_total", "passes_accurate", "passes_percent", "expected_goals",
+}
+
+// CopyTeamsStats writes team statistics with COPY into a staging table and
+// merges them in one statement, rows already present are skipped.
+func (fm *FixtureRepo) CopyTeamsStats(rows []*TeamStatisticsRow) (int64, error) {
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	ctx := context.Background()
+	tx, err := fm.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, createTeamStatisticsStaging); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"team_statistics_staging"}, teamStatisticsColumns,
+		pgx.CopyFromSlice(len(rows), func(i int) ([]interface{}, error) {
+			return rows[i].values(), nil
+		}))
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := tx.Exec(ctx, mergeTeamStatistics)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
@@ -0,0 +1,179 @@
+package fixture
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/bernhardson/prefoot/pkg/players"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The benchmarks write rows of negative ids, which the provider never
+// uses, to the database at PREFOOT_TEST_DSN and delete them again.
+const (
+	benchFixtures       = 50
+	benchPlayersPerTeam = 16
+
+	setupBenchPlayers = `INSERT INTO people (id) SELECT -g FROM generate_series(1, $1::int) g ON CONFLICT DO NOTHING`
+	deleteBenchStats  = `DELETE FROM team_statistics WHERE fixture < 0;
+							DELETE FROM player_statistics WHERE fixture < 0`
+	deleteBenchPlayers = `DELETE FROM players WHERE id < 0`
+	deleteBenchPeople  = `DELETE FROM people WHERE id < 0`
+)
+
+func benchPool(b *testing.B) *pgxpool.Pool {
+
+	dsn := os.Getenv("PREFOOT_TEST_DSN")
+	if dsn == "" {
+		b.Skip("PREFOOT_TEST_DSN is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		for _, q := range []string{deleteBenchStats, deleteBenchPlayers, deleteBenchPeople} {
+			if _, err := pool.Exec(context.Background(), q); err != nil {
+				b.Error(err)
+			}
+		}
+		pool.Close()
+	})
+	return pool
+}
+
+// benchExec runs q with the timer stopped, e.g. to delete the rows the
+// last iteration wrote.
+func benchExec(b *testing.B, pool *pgxpool.Pool, q string, args ...interface{}) {
+	b.StopTimer()
+	defer b.StartTimer()
+	if _, err := pool.Exec(context.Background(), q, args...); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// benchPlayers returns a player of each team of the benchmarked fixtures.
+func benchPlayers() []*players.PlayerRow {
+
+	var rows []*players.PlayerRow
+	for i := 1; i <= 2*benchPlayersPerTeam; i++ {
+		team := -1
+		if i > benchPlayersPerTeam {
+			team = -2
+		}
+		rows = append(rows, &players.PlayerRow{Id: -i, Team: team})
+	}
+	return rows
+}
+
+func benchTeamStats() []*TeamStatisticsRow {
+
+	var rows []*TeamStatisticsRow
+	for f := 1; f <= benchFixtures; f++ {
+		for _, team := range []int{-1, -2} {
+			rows = append(rows, &TeamStatisticsRow{Team: team, Fixture: -f, ShotsTotal: 12, ShotsOn: 5, Possession: 50, ExpectedGoals: 1.4})
+		}
+	}
+	return rows
+}
+
+func benchPlayerStats() []*players.PlayerStatsRow {
+
+	var rows []*players.PlayerStatsRow
+	for f := 1; f <= benchFixtures; f++ {
+		for _, p := range benchPlayers() {
+			rows = append(rows, &players.PlayerStatsRow{Player: p.Id, Fixture: -f, Team: p.Team, Minutes: 90, Position: "M", Rating: 6.8})
+		}
+	}
+	return rows
+}
+
+// BenchmarkIngestPlayers compares inserting the players of a league
+// season one by one with one batch.
+func BenchmarkIngestPlayers(b *testing.B) {
+
+	pool := benchPool(b)
+	repo := &players.Repo{Pool: pool}
+	rows := benchPlayers()
+	benchExec(b, pool, setupBenchPlayers, len(rows))
+
+	b.Run("row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchExec(b, pool, deleteBenchPlayers)
+			for _, r := range rows {
+				if _, err := repo.Insert(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchExec(b, pool, deleteBenchPlayers)
+			if _, err := repo.InsertBatch(rows); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkIngestTeamStatistics compares inserting the team statistics of
+// a batch of fixtures row by row with COPY.
+func BenchmarkIngestTeamStatistics(b *testing.B) {
+
+	pool := benchPool(b)
+	repo := &FixtureRepo{Pool: pool}
+	rows := benchTeamStats()
+
+	b.Run("row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchExec(b, pool, deleteBenchStats)
+			for _, r := range rows {
+				if _, err := repo.InsertTeamsStats(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchExec(b, pool, deleteBenchStats)
+			if _, err := repo.CopyTeamsStats(rows); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkIngestPlayerStatistics compares inserting the player statistics
+// of a batch of fixtures row by row with COPY.
+func BenchmarkIngestPlayerStatistics(b *testing.B) {
+
+	pool := benchPool(b)
+	repo := &players.Repo{Pool: pool}
+	benchExec(b, pool, setupBenchPlayers, 2*benchPlayersPerTeam)
+	if _, err := repo.InsertBatch(benchPlayers()); err != nil {
+		b.Fatal(err)
+	}
+	rows := benchPlayerStats()
+
+	b.Run("row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchExec(b, pool, deleteBenchStats)
+			for _, r := range rows {
+				if _, err := repo.InsertStats(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchExec(b, pool, deleteBenchStats)
+			if _, _, err := repo.CopyStats(rows); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package fixture
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bernhardson/prefoot/pkg/versions"
+)
+
+// entity name of fixtures in entity_versions and change_log
+const fixtureEntity = "fixture"
+
+// fields whose change alters the results and with them the standings
+var resultFields = []string{"goals.", "status.", "teams."}
+
+// snapshot flattens the parts of a fixture detail that are stored into
+// fields named like "goals.home" or "players.<id>.rating". Fields are
+// keyed by ids rather than positions so a reordered response is equal.
+func snapshot(fd *FixtureDetail) map[string]interface{} {
+
+	s := map[string]interface{}{
+		"status.short":        fd.Fixture.Status.Short,
+		"status.elapsed":      fd.Fixture.Status.Elapsed,
+		"timestamp":           fd.Fixture.Timestamp,
+		"referee":             fd.Fixture.Referee,
+		"venue":               fd.Fixture.Venue.ID,
+		"teams.home.id":       fd.Teams.Home.ID,
+		"teams.home.winner":   fd.Teams.Home.Winner,
+		"teams.away.id":       fd.Teams.Away.ID,
+		"teams.away.winner":   fd.Teams.Away.Winner,
+		"goals.home":          fd.Goals.Home,
+		"goals.away":          fd.Goals.Away,
+		"score.halftime.home": fd.Score.Halftime.Home,
+		"score.halftime.away": fd.Score.Halftime.Away,
+	}
+
+	for _, ts := range fd.Statistics {
+		for _, st := range ts.Statistics {
+			s[fmt.Sprintf("statistics.%d.%s", ts.Team.ID, st.Type)] = st.Value
+		}
+	}
+
+	for _, l := range fd.Lineups {
+		prefix := fmt.Sprintf("lineups.%d.", l.Team.ID)
+		s[prefix+"formation"] = l.Formation
+		s[prefix+"coach"] = l.Coach.ID
+		ids := make([]int, 0, len(l.StartXI)+len(l.Substitutes))
+		for _, p := range l.StartXI {
+			ids = append(ids, p.Player.ID)
+		}
+		for _, p := range l.Substitutes {
+			ids = append(ids, p.Player.ID)
+		}
+		s[prefix+"players"] = ids
+	}
+
+	for _, pt := range fd.Players {
+		for _, p := range pt.Players {
+			if len(p.Statistics) == 0 {
+				continue
+			}
+			ps := p.Statistics[0]
+			prefix := fmt.Sprintf("players.%d.", p.Player.ID)
+			s[prefix+"team"] = pt.Team.ID
+			s[prefix+"rating"] = ps.Games.Rating
+			s[prefix+"minutes"] = ps.Games.Minutes
+			s[prefix+"position"] = ps.Games.Position
+			s[prefix+"goals"] = ps.Goals.Total
+			s[prefix+"assists"] = ps.Goals.Assists
+			s[prefix+"saves"] = ps.Goals.Saves
+			s[prefix+"shots"] = []int{ps.Shots.Total, ps.Shots.On}
+			s[prefix+"passes"] = []interface{}{ps.Passes.Total, ps.Passes.Key, ps.Passes.Accuracy}
+			s[prefix+"tackles"] = []int{ps.Tackles.Total, ps.Tackles.Blocks, ps.Tackles.Interceptions}
+			s[prefix+"duels"] = []int{ps.Duels.Total, ps.Duels.Won}
+			s[prefix+"dribbles"] = []int{ps.Dribbles.Attempts, ps.Dribbles.Success}
+			s[prefix+"cards"] = []int{ps.Cards.Yellow, ps.Cards.Red}
+			s[prefix+"penalty"] = []int{ps.Penalty.Won, ps.Penalty.Commited, ps.Penalty.Scored, ps.Penalty.Missed, ps.Penalty.Saved}
+		}
+	}
+	return s
+}
+
+// compare compares fd with the stored version of the fixture. It returns
+// nil if the fixture is unchanged and needs no write. Without a version
+// repository every fixture counts as new.
+func (fm *FixtureModel) compare(fd *FixtureDetail) *versions.Change {
+
+	if fm.VersionRepo == nil {
+		return &versions.Change{Entity: fixtureEntity, ID: fd.Fixture.ID, Created: true}
+	}
+	c, err := fm.VersionRepo.Compare(fixtureEntity, fd.Fixture.ID, snapshot(fd))
+	if err != nil {
+		// write anyway, a failing version check must not stop ingestion
+		fm.Logger.Err(err).Msg(fmt.Sprintf("compare fixture version: fixture_%d", fd.Fixture.ID))
+		return &versions.Change{Entity: fixtureEntity, ID: fd.Fixture.ID, Created: true}
+	}
+	if c != nil && !c.Created {
+		fields := make([]string, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			fields = append(fields, f.Field)
+		}
+		fm.Logger.Info().Msg(fmt.Sprintf("fixture changed: fixture_%d#fields=%s", fd.Fixture.ID, strings.Join(fields, ",")))
+	}
+	return c
+}
+
+// commit stores the versions of fixtures whose writes all succeeded.
+// Fixtures that failed keep their old version and are written again by
+// the next update.
+func (fm *FixtureModel) commit(changes []*versions.Change) {
+	if fm.VersionRepo == nil {
+		return
+	}
+	for _, c := range changes {
+		if err := fm.VersionRepo.Commit(c); err != nil {
+			fm.Logger.Err(err).Msg(fmt.Sprintf("commit fixture version: fixture_%d", c.ID))
+		}
+	}
+}
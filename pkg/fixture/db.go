@@ -3,15 +3,20 @@ package fixture
 import (
 	"context"
 
+	"github.com/bernhardson/prefoot/pkg/result"
+	"github.com/bernhardson/prefoot/pkg/team"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	insertFixture = `INSERT INTO fixtures (id, league, round, referee, timezone, timestamp, venue, season, home_team, away_team,
+	insertFixture = `INSERT INTO fixtures (id, league, round, referee, referee_id, timezone, timestamp, venue, season, home_team, away_team,
 						home_goals, away_goals, home_goals_half, away_goals_half)
-						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);`
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15);`
 	deleteFixture        = `DELETE FROM fixtures WHERE id = $1;`
+	deleteFormations     = `DELETE FROM formations WHERE fixture = $1`
+	deleteTeamStats      = `DELETE FROM team_statistics WHERE fixture = $1`
+	deletePlayerStats    = `DELETE FROM player_statistics WHERE fixture = $1`
 	insertTeamStatistics = "INSERT INTO team_statistics " +
 		"(team, fixture, shots_total, shots_on, shots_off, shots_blocked, " +
 		"shots_box, shots_outside, offsides, fouls, corners, possession, yellow, red, " +
@@ -21,10 +26,15 @@ const (
 	insertFormation = `INSERT INTO formations (fixture, team, formation, player1, player2, player3, player4, player5, player6, player7, player8, player9, player10, player11, sub1, sub2, sub3, sub4, sub5, coach)
 						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`
 
+	selectFixture                     = `SELECT * FROM fixtures WHERE id = $1`
 	selectFixturesByRound             = "SELECT * FROM fixtures WHERE round = $1"
 	selectFixturesByLeagueSeasonRound = `SELECT * FROM "fixtures" WHERE "league" = $1 AND "season" = $2 AND "round" = $3`
 	selectFixturesByLastNRounds       = `SELECT id FROM fixtures WHERE league=$1 AND season=$2 AND round BETWEEN $3 and $4`
 
+	selectFixtureWithTeams                     = `SELECT f.*, CASE WHEN h.id IS NOT NULL THEN to_jsonb(h) END AS home, CASE WHEN a.id IS NOT NULL THEN to_jsonb(a) END AS away FROM fixtures f LEFT JOIN teams h ON h.id = f.home_team LEFT JOIN teams a ON a.id = f.away_team WHERE f.id = $1`
+	selectFixturesWithTeamsByLeagueSeasonRound = `SELECT f.*, CASE WHEN h.id IS NOT NULL THEN to_jsonb(h) END AS home, CASE WHEN a.id IS NOT NULL THEN to_jsonb(a) END AS away FROM fixtures f LEFT JOIN teams h ON h.id = f.home_team LEFT JOIN teams a ON a.id = f.away_team WHERE f.league = $1 AND f.season = $2 AND f.round = $3 ORDER BY f.timestamp, f.id`
+	selectLastNFixturesWithResultsByTeam       = `SELECT f.*, to_jsonb(r) AS result FROM fixtures f JOIN results r ON r.league = f.league AND r.season = f.season AND r.round = f.round AND r.team = $1 WHERE (f.home_team = $1 OR f.away_team = $1) AND f.timestamp < $2 ORDER BY f.timestamp DESC LIMIT $3`
+
 	selectLastNFixturesByTeams = `SELECT * FROM fixtures WHERE (home_team = $1 AND away_team = $2) OR (home_team = $2 AND away_team = $1) ORDER BY timestamp DESC LIMIT $3;`
 	selectLastNFixturesByTeam  = `SELECT * FROM fixtures WHERE (home_team = $1 OR away_team = $1) AND timestamp < $2 ORDER BY timestamp DESC LIMIT $3;`
 )
@@ -38,9 +48,10 @@ type FixtureRow struct {
 	League        int    `json:"league"`
 	Round         int    `json:"round"`
 	Referee       string `json:"referee"`
+	RefereeID     *int   `json:"referee_id"`
 	Timezone      string `json:"timezone"`
 	Timestamp     int    `json:"timestamp"`
-	Venue         int    `json:"venue"`
+	Venue         *int   `json:"venue"`
 	Season        int    `json:"season"`
 	HomeTeam      int    `json:"home_team"`
 	AwayTeam      int    `json:"away_team"`
@@ -55,7 +66,7 @@ func (fm *FixtureRepo) Insert(f *FixtureRow) (int64, error) {
 	row, err := fm.Pool.Exec(
 		context.Background(),
 		insertFixture,
-		f.ID, f.League, f.Round, f.Referee, f.Timezone,
+		f.ID, f.League, f.Round, f.Referee, f.RefereeID, f.Timezone,
 		f.Timestamp, f.Venue, f.Season, f.HomeTeam,
 		f.AwayTeam, f.HomeGoals, f.AwayGoals, f.HomeGoalsHalf,
 		f.AwayGoalsHalf)
@@ -86,14 +97,17 @@ type TeamStatisticsRow struct {
 }
 
 func (fm *FixtureRepo) InsertTeamsStats(t *TeamStatisticsRow) (int64, error) {
-	row, err := fm.Pool.Exec(
-		context.Background(),
-		insertTeamStatistics,
+	row, err := fm.Pool.Exec(context.Background(), insertTeamStatistics, t.values()...)
+	return row.RowsAffected(), err
+}
+
+// values in the column order of insertTeamStatistics
+func (t *TeamStatisticsRow) values() []interface{} {
+	return []interface{}{
 		t.Team, t.Fixture, t.ShotsTotal, t.ShotsOn, t.ShotsOff, t.ShotsBlocked,
 		t.ShotsBox, t.ShotsOutside, t.Offsides, t.Fouls, t.Corners, t.Possession, t.Yellow, t.Red,
 		t.GKSaves, t.PassesTotal, t.PassesAccurate, t.PassesPercent, t.ExpectedGoals,
-	)
-	return row.RowsAffected(), err
+	}
 }
 
 type FormationRow struct {
@@ -134,6 +148,17 @@ func (fm *FixtureRepo) InsertFormation(f *FormationRow) (int64, error) {
 	return row.RowsAffected(), err
 }
 
+func (pm *FixtureRepo) Select(id int) (*FixtureRow, error) {
+
+	rows, err := pm.Pool.Query(context.Background(), selectFixture, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[FixtureRow])
+}
+
 func (pm *FixtureRepo) SelectFixturesByRound(round int) ([]*FixtureRow, error) {
 
 	rows, err := pm.Pool.Query(
@@ -166,14 +191,28 @@ func (pm *FixtureRepo) SelectFixtureByLeagueSeasonRound(league, season, round in
 	return fixture, nil
 }
 
+// DeleteFixture deletes a fixture with its formations and statistics so it
+// can be written again.
 func (fm *FixtureRepo) DeleteFixture(id int) (int64, error) {
 
-	result, err := fm.Pool.Exec(context.Background(), deleteFixture, id)
+	ctx := context.Background()
+	tx, err := fm.Pool.Begin(ctx)
 	if err != nil {
 		return -1, err
 	}
+	defer tx.Rollback(ctx)
 
-	return result.RowsAffected(), nil
+	for _, q := range []string{deleteFormations, deleteTeamStats, deletePlayerStats} {
+		if _, err := tx.Exec(ctx, q, id); err != nil {
+			return -1, err
+		}
+	}
+	result, err := tx.Exec(ctx, deleteFixture, id)
+	if err != nil {
+		return -1, err
+	}
+
+	return result.RowsAffected(), tx.Commit(ctx)
 }
 
 func (fm *FixtureRepo) SelectFixtureIdsForLastNRounds(league, season, round, n int) (*[]int, error) {
@@ -215,6 +254,61 @@ func (pm *FixtureRepo) SelectLastNMatchups(team1, team2, n int) ([]*FixtureRow,
 	return fixtures, nil
 }
 
+// FixtureTeamsRow is a fixture joined with its home and away team. A team
+// is nil if its row is missing.
+type FixtureTeamsRow struct {
+	FixtureRow
+	Home *team.TeamRow `json:"home"`
+	Away *team.TeamRow `json:"away"`
+}
+
+// SelectWithTeams returns a fixture joined with both teams.
+func (pm *FixtureRepo) SelectWithTeams(id int) (*FixtureTeamsRow, error) {
+
+	rows, err := pm.Pool.Query(context.Background(), selectFixtureWithTeams, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[FixtureTeamsRow])
+}
+
+// SelectWithTeamsByLeagueSeasonRound returns the fixtures of a round joined
+// with both teams in a single query.
+func (pm *FixtureRepo) SelectWithTeamsByLeagueSeasonRound(league, season, round int) ([]*FixtureTeamsRow, error) {
+
+	rows, err := pm.Pool.Query(
+		context.Background(), selectFixturesWithTeamsByLeagueSeasonRound, league, season, round)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[FixtureTeamsRow])
+}
+
+// FixtureResultRow is a fixture joined with the result of one team.
+type FixtureResultRow struct {
+	FixtureRow
+	Result *result.ResultRow `json:"result"`
+}
+
+// SelectLastNFixturesWithResultsByTeam returns the last n fixtures of a team
+// before ts joined with the team's results. Fixtures without a result are
+// left out.
+func (pm *FixtureRepo) SelectLastNFixturesWithResultsByTeam(team, ts, n int) ([]*FixtureResultRow, error) {
+
+	rows, err := pm.Pool.Query(
+		context.Background(), selectLastNFixturesWithResultsByTeam, team, ts, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[FixtureResultRow])
+}
+
 func (pm *FixtureRepo) SelectLastNFixturesByTeam(team, ts, n int) ([]*FixtureRow, error) {
 
 	rows, err := pm.Pool.Query(
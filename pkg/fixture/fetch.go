@@ -1,6 +1,7 @@
 package fixture
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -314,9 +315,9 @@ type TeamStatisticsFD struct {
 	ExpectedGoals  float64 `json:"expected_goals"`
 }
 
-func FetchFixtures(league int, season int) (*FixtureResponse, error) {
+func FetchFixtures(c *comm.Client, league int, season int) (*FixtureResponse, error) {
 
-	data, err := comm.GetHttpBody(fixturesURL, league, season)
+	data, err := c.GetHttpBody(fixturesURL, league, season)
 	if err != nil {
 		return nil, err
 	}
@@ -329,9 +330,13 @@ func FetchFixtures(league int, season int) (*FixtureResponse, error) {
 	return matches, nil
 }
 
-func GetFixtureDetail(id int) (*FixtureDetailResponse, error) {
+func GetFixtureDetail(c *comm.Client, id int) (*FixtureDetailResponse, error) {
+	return GetFixtureDetailContext(context.Background(), c, id)
+}
+
+func GetFixtureDetailContext(ctx context.Context, c *comm.Client, id int) (*FixtureDetailResponse, error) {
 
-	data, err := comm.GetHttpBody(fixtureDetailURL, id)
+	data, err := c.GetHttpBodyContext(ctx, fixtureDetailURL, id)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,146 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"golang.org/x/sync/errgroup"
+)
+
+// number of fixture details fetched concurrently, the comm.Provider
+// limiter still bounds the request rate
+const fetchWorkers = 4
+
+// log progress every n written fixtures
+const progressEvery = 25
+
+// detailJob is one fixture whose details are fetched.
+type detailJob struct {
+	id    int
+	round int
+}
+
+// FetchErrors collects the fixtures whose details could not be fetched or
+// written by id.
+type FetchErrors struct {
+	Failed map[int]error
+}
+
+func (e *FetchErrors) Error() string {
+	ids := make([]int, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	msgs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("fixture_%d: %v", id, e.Failed[id]))
+	}
+	return fmt.Sprintf("fixture details: %d failed#%s", len(ids), strings.Join(msgs, "#"))
+}
+
+// withWriteErrors adds the fixtures that failed to be written to the
+// *FetchErrors of err, which is returned by fetchDetails.
+func withWriteErrors(err error, failed map[int]error) error {
+
+	if len(failed) == 0 {
+		return err
+	}
+	var fe *FetchErrors
+	if errors.As(err, &fe) {
+		for id, e := range failed {
+			fe.Failed[id] = errors.Join(fe.Failed[id], e)
+		}
+		return err
+	}
+	if err == nil {
+		return &FetchErrors{Failed: failed}
+	}
+	return errors.Join(err, &FetchErrors{Failed: failed})
+}
+
+// fetchDetails fetches the details of jobs with a bounded pool of workers
+// and hands them to write, which runs on a single goroutine so it can use
+// the repositories and batches without locking. A failing fixture is
+// recorded and skipped; errors affecting every request, such as an
+// exhausted quota, cancel the remaining fetches. The returned error is a
+// *FetchErrors, joined with the cancellation cause if there was one.
+func (fm *FixtureModel) fetchDetails(ctx context.Context, jobs []detailJob, write func(detailJob, *FixtureDetailResponse)) error {
+
+	type fetched struct {
+		job    detailJob
+		detail *FixtureDetailResponse
+	}
+
+	var (
+		mu      sync.Mutex
+		failed  = make(map[int]error)
+		written atomic.Int64
+	)
+	fail := func(id int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed[id] = err
+	}
+
+	details := make(chan fetched, fetchWorkers)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for d := range details {
+			write(d.job, d.detail)
+			if n := written.Add(1); n%progressEvery == 0 {
+				mu.Lock()
+				fm.Logger.Info().Msg(fmt.Sprintf("fixture details: written=%d#total=%d#failed=%d", n, len(jobs), len(failed)))
+				mu.Unlock()
+			}
+		}
+	}()
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(fetchWorkers)
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		job := job
+		g.Go(func() error {
+			fd, err := GetFixtureDetailContext(ctx, fm.Client, job.id)
+			if err != nil {
+				var se *comm.StatusError
+				if errors.As(err, &se) && se.Fatal() {
+					return err
+				}
+				fail(job.id, err)
+				return nil
+			}
+			select {
+			case details <- fetched{job: job, detail: fd}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	cause := g.Wait()
+	close(details)
+	<-done
+
+	fm.Logger.Info().Msg(fmt.Sprintf("fixture details: written=%d#total=%d#failed=%d", written.Load(), len(jobs), len(failed)))
+
+	var err error
+	if len(failed) > 0 {
+		err = &FetchErrors{Failed: failed}
+	}
+	if cause != nil {
+		err = errors.Join(cause, err)
+	}
+	return err
+}
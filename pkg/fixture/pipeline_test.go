@@ -0,0 +1,75 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithWriteErrors(t *testing.T) {
+
+	errFetch := errors.New("fetch")
+	errWrite := errors.New("write")
+
+	tests := []struct {
+		name   string
+		err    error
+		failed map[int]error
+		want   map[int]error
+		cause  error
+	}{
+		{
+			name: "no errors",
+		},
+		{
+			name:   "write errors only",
+			failed: map[int]error{1: errWrite},
+			want:   map[int]error{1: errWrite},
+		},
+		{
+			name:   "fetch and write errors",
+			err:    &FetchErrors{Failed: map[int]error{1: errFetch}},
+			failed: map[int]error{2: errWrite},
+			want:   map[int]error{1: errFetch, 2: errWrite},
+		},
+		{
+			name:   "write errors after a cancellation",
+			err:    errors.Join(context.Canceled, &FetchErrors{Failed: map[int]error{1: errFetch}}),
+			failed: map[int]error{1: errWrite},
+			want:   map[int]error{1: errors.Join(errFetch, errWrite)},
+			cause:  context.Canceled,
+		},
+		{
+			name:   "cancellation without fetch errors",
+			err:    context.Canceled,
+			failed: map[int]error{2: errWrite},
+			want:   map[int]error{2: errWrite},
+			cause:  context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := withWriteErrors(tt.err, tt.failed)
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("withWriteErrors() = %v, want it to wrap %v", err, tt.cause)
+			}
+
+			var fe *FetchErrors
+			if !errors.As(err, &fe) {
+				if tt.want != nil {
+					t.Fatalf("withWriteErrors() = %v, want a *FetchErrors", err)
+				}
+				return
+			}
+			if len(fe.Failed) != len(tt.want) {
+				t.Fatalf("failed fixtures = %v, want %v", fe.Failed, tt.want)
+			}
+			for id, want := range tt.want {
+				if got := fe.Failed[id]; got == nil || got.Error() != want.Error() {
+					t.Errorf("fixture_%d: %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package fixture
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -10,58 +12,93 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/bernhardson/prefoot/pkg/leagues"
 	"github.com/bernhardson/prefoot/pkg/players"
+	"github.com/bernhardson/prefoot/pkg/referee"
 	"github.com/bernhardson/prefoot/pkg/result"
 	"github.com/bernhardson/prefoot/pkg/rounds"
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/bernhardson/prefoot/pkg/team"
+	"github.com/bernhardson/prefoot/pkg/versions"
 )
 
 type FixtureModel struct {
 	Logger *zerolog.Logger
+	Client *comm.Client
 	Repo   interface {
 		Insert(*FixtureRow) (int64, error)
+		Select(int) (*FixtureRow, error)
 		InsertTeamsStats(*TeamStatisticsRow) (int64, error)
+		CopyTeamsStats([]*TeamStatisticsRow) (int64, error)
 		InsertFormation(*FormationRow) (int64, error)
 		SelectFixturesByRound(int) ([]*FixtureRow, error)
 		SelectFixtureByLeagueSeasonRound(int, int, int) ([]*FixtureRow, error)
 		SelectFixtureIdsForLastNRounds(int, int, int, int) (*[]int, error)
 		SelectLastNMatchups(int, int, int) ([]*FixtureRow, error)
 		SelectLastNFixturesByTeam(int, int, int) ([]*FixtureRow, error)
+		SelectWithTeams(int) (*FixtureTeamsRow, error)
+		SelectWithTeamsByLeagueSeasonRound(int, int, int) ([]*FixtureTeamsRow, error)
+		SelectLastNFixturesWithResultsByTeam(int, int, int) ([]*FixtureResultRow, error)
 		DeleteFixture(int) (int64, error)
 	}
 
-	RoundRepo  *rounds.Repo
-	PlayerRepo *players.Repo
-	ResultRepo *result.ResultRepo
+	RoundRepo   *rounds.Repo
+	PlayerRepo  *players.Repo
+	ResultRepo  *result.ResultRepo
+	VersionRepo *versions.Repo
+	VenueRepo   *team.VenueRepository
+	RefereeRepo *referee.Repo
+	// Leagues provides the season coverage, without it everything is
+	// assumed to be covered
+	Leagues *leagues.LeaguesModel
 }
 
 // Initialize fixtures, formations, team_statistics, player_statistics, rounds tables.
 // Queries Rapid API then insert into local postgres.
 // Some data manipulation is done on the fly.
+// Fixture details are fetched concurrently, fixtures failing to fetch or
+// write are skipped and reported in the returned *FetchErrors.
 func (fm *FixtureModel) FetchAndInsertFixtures(league, season int) error {
 
-	fr, err := FetchFixtures(league, season)
+	fr, err := FetchFixtures(fm.Client, league, season)
 	if err != nil {
 		log.Err(err).Msg("")
 		return err
 	}
 
-	for _, f := range fr.Response {
-		round := 0
-		//insert league
-		fd, err := GetFixtureDetail(f.Fixture.ID)
+	run := shared.NewThroughput()
+	b := &statsBatch{coverage: fm.coverage(league, season)}
 
-		if err != nil {
-			return err
-		}
-		round, err = strconv.Atoi(extractDigits(f.League.Round))
+	if !detailed(b.coverage) {
+		// nothing the details add is covered, the list has all there is
+		fm.collectListed(fr, nil, league, season, b, run)
+		fm.Logger.Info().Msg(fmt.Sprintf("ingest fixtures without details: league=%d#season=%d#%s", league, season, run))
+		return withWriteErrors(nil, b.failed)
+	}
+
+	jobs := make([]detailJob, 0, len(fr.Response))
+	for _, f := range fr.Response {
+		round, err := strconv.Atoi(extractDigits(f.League.Round))
 		if err != nil {
 			fm.Logger.Err(err).Msg("")
 		}
-		fm.InsertFixture(&fd.FixtureDetail, league, season, round)
-		fm.Logger.Info().Msg(fmt.Sprintf("Inserted fixture=%d", f.Fixture.ID))
+		jobs = append(jobs, detailJob{id: f.Fixture.ID, round: round})
 	}
 
-	return nil
+	written := 0
+	err = fm.fetchDetails(context.Background(), jobs, func(job detailJob, fd *FixtureDetailResponse) {
+		fm.collectFixture(&fd.FixtureDetail, league, season, job.round, b, run)
+		fm.Logger.Debug().Msg(fmt.Sprintf("Inserted fixture=%d", job.id))
+
+		if written++; written%flushFixtures == 0 {
+			fm.flush(b, run)
+		}
+	})
+	fm.flush(b, run)
+
+	fm.Logger.Info().Msg(fmt.Sprintf("ingest fixtures: league=%d#season=%d#%s", league, season, run))
+	return withWriteErrors(err, b.failed)
 }
 
 func (fm *FixtureModel) UpdateFixture(league, season int) error {
@@ -75,18 +112,107 @@ func (fm *FixtureModel) UpdateFixture(league, season int) error {
 	if err != nil {
 		return err
 	}
-	for _, f := range fixtures {
-		fD, err := GetFixtureDetail(f.ID)
+	run := shared.NewThroughput()
+	b := &statsBatch{coverage: fm.coverage(league, season)}
+
+	if !detailed(b.coverage) {
+		fr, err := FetchFixtures(fm.Client, league, season)
 		if err != nil {
 			return err
 		}
-		_, err = fm.Repo.DeleteFixture(f.ID)
+		ids := make(map[int]bool, len(fixtures))
+		for _, f := range fixtures {
+			ids[f.ID] = true
+		}
+		fm.collectListed(fr, ids, league, season, b, run)
+		fm.Logger.Info().Msg(fmt.Sprintf("update fixtures without details: league=%d#season=%d#round=%d#%s", league, season, row.Round, run))
+		return withWriteErrors(nil, b.failed)
+	}
+
+	jobs := make([]detailJob, 0, len(fixtures))
+	for _, f := range fixtures {
+		jobs = append(jobs, detailJob{id: f.ID, round: f.Round})
+	}
+
+	err = fm.fetchDetails(context.Background(), jobs, func(job detailJob, fd *FixtureDetailResponse) {
+		fm.collectFixture(&fd.FixtureDetail, league, season, job.round, b, run)
+	})
+	fm.flush(b, run)
+
+	fm.Logger.Info().Msg(fmt.Sprintf("update fixtures: league=%d#season=%d#round=%d#%s", league, season, row.Round, run))
+	return withWriteErrors(err, b.failed)
+}
+
+// number of fixtures whose statistics are written in one bulk write
+const flushFixtures = 50
+
+// statsBatch accumulates the statistics rows of fixtures for bulk writes.
+// Statistics the provider does not cover for the season are not collected.
+type statsBatch struct {
+	teamStats   []*TeamStatisticsRow
+	playerStats []*players.PlayerStatsRow
+	coverage    leagues.SeasonCoverageFixtures
+	// versions of the collected fixtures, committed once their statistics
+	// are written
+	changes []*versions.Change
+	// fixtures that could not be written by id
+	failed map[int]error
+}
+
+// fail records that fixture id could not be written.
+func (b *statsBatch) fail(id int, err error) {
+	if b.failed == nil {
+		b.failed = make(map[int]error)
+	}
+	b.failed[id] = errors.Join(b.failed[id], err)
+}
+
+// coverage returns what the provider covers for fixtures of the season.
+func (fm *FixtureModel) coverage(league, season int) leagues.SeasonCoverageFixtures {
+	if fm.Leagues == nil {
+		return leagues.FullCoverage.Fixtures
+	}
+	return fm.Leagues.Coverage(league, season).Fixtures
+}
+
+// detailed reports whether fixture details hold anything covered that the
+// fixture list lacks.
+func detailed(c leagues.SeasonCoverageFixtures) bool {
+	return c.Lineups || c.StatisticsFixtures || c.StatisticsPlayers
+}
+
+// collectListed writes fixtures of the list response without fetching
+// their details. If ids is not nil only those fixtures are written.
+func (fm *FixtureModel) collectListed(fr *FixtureResponse, ids map[int]bool, league, season int, b *statsBatch, run *shared.Throughput) {
+
+	for _, f := range fr.Response {
+		if ids != nil && !ids[f.Fixture.ID] {
+			continue
+		}
+		round, err := strconv.Atoi(extractDigits(f.League.Round))
 		if err != nil {
-			return err
+			fm.Logger.Err(err).Msg("")
 		}
-		fm.InsertFixture(&fD.FixtureDetail, league, season, f.Round)
+		fd := []FixtureDetail{{
+			Fixture: FixtureFD{
+				ID:        f.Fixture.ID,
+				Referee:   f.Fixture.Referee,
+				Timezone:  f.Fixture.Timezone,
+				Timestamp: f.Fixture.Timestamp,
+				Venue:     f.Fixture.Venue,
+				Status:    f.Fixture.Status,
+			},
+			League: f.League,
+			Teams: Teams{
+				Home: TeamFD(f.Teams.Home),
+				Away: TeamFD(f.Teams.Away),
+			},
+			Goals: f.Goals,
+			Score: f.Score,
+		}}
+		fm.collectFixture(&fd, league, season, round, b, run)
 	}
-	return nil
+	fm.flush(b, run)
 }
 
 // Loops at fixtures f and triggers their data base insert.
@@ -95,7 +221,31 @@ func (fm *FixtureModel) UpdateFixture(league, season int) error {
 // we insert those to database as well while the information is available
 func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round int) {
 
+	run := shared.NewThroughput()
+	b := &statsBatch{coverage: fm.coverage(league, season)}
+	fm.collectFixture(fr, league, season, round, b, run)
+	fm.flush(b, run)
+}
+
+// collectFixture inserts fixtures, rounds, results and formations and
+// collects the team and player statistics into b. Fixtures equal to their
+// stored version are skipped, others are deleted and written again.
+// Results are only recomputed if the score or status changed.
+func (fm *FixtureModel) collectFixture(fr *[]FixtureDetail, league, season, round int, b *statsBatch, run *shared.Throughput) {
+
 	for _, fd := range *fr {
+		change := fm.compare(&fd)
+		if change == nil {
+			run.Add("fixtures_unchanged", 1)
+			continue
+		}
+		// new fixtures may have been written partially by a failed update
+		if _, err := fm.Repo.DeleteFixture(fd.Fixture.ID); err != nil {
+			fm.Logger.Err(err).Msg(fmt.Sprintf("delete fixture: fixture_%d", fd.Fixture.ID))
+			b.fail(fd.Fixture.ID, fmt.Errorf("delete fixture: %w", err))
+			continue
+		}
+
 		start, err := fm.RoundRepo.SelectTimestampFromRounds(league, season, round)
 		end := -1
 		if err != nil {
@@ -131,6 +281,9 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 			fm.Logger.Err(err).Msg("")
 		}
 
+		venue := fm.ensureVenue(&fd)
+		referee := fm.ensureReferee(&fd)
+
 		fm.Logger.Debug().Msg(fmt.Sprintf("insert fixture :%d", fd.Fixture.ID))
 		//insert fixture
 		_, err = fm.Repo.Insert(&FixtureRow{
@@ -138,9 +291,10 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 			League:        fd.League.ID,
 			Round:         round,
 			Referee:       fd.Fixture.Referee,
+			RefereeID:     referee,
 			Timezone:      fd.Fixture.Timezone,
 			Timestamp:     fd.Fixture.Timestamp,
-			Venue:         fd.Fixture.Venue.ID,
+			Venue:         venue,
 			Season:        season,
 			HomeTeam:      fd.Teams.Home.ID,
 			AwayTeam:      fd.Teams.Away.ID,
@@ -151,41 +305,46 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 		})
 		if err != nil {
 			fm.Logger.Err(err).Msg(fmt.Sprintf("insert fixture: fixture_%d", fd.Fixture.ID))
+			b.fail(fd.Fixture.ID, fmt.Errorf("insert fixture: %w", err))
+		} else {
+			run.Add("fixtures", 1)
 		}
 
 		if fd.Fixture.Status.Elapsed > 0 { //calculate and insert results
-			home, away := calculateResult(&fd, league, season, round)
-			fm.ResultRepo.Insert(home)
-			fm.ResultRepo.Insert(away)
+			if change.Affects(resultFields...) {
+				if err := fm.writeResults(&fd, league, season, round, run); err != nil {
+					b.fail(fd.Fixture.ID, err)
+				}
+			}
 
 			for i, l := range fd.Lineups {
-				ts := convertTeamStatistics(i, &fd, fm.Logger)
-				_, err := fm.Repo.InsertTeamsStats(&TeamStatisticsRow{
-					Team:           l.Team.ID,
-					Fixture:        fd.Fixture.ID,
-					ShotsTotal:     ts.ShotsTotal,
-					ShotsOn:        ts.ShotsOn,
-					ShotsOff:       ts.ShotsOff,
-					ShotsBlocked:   ts.ShotsBlocked,
-					ShotsBox:       ts.ShotsBox,
-					ShotsOutside:   ts.ShotsOutside,
-					Offsides:       ts.Offsides,
-					Fouls:          ts.Fouls,
-					Corners:        ts.Corners,
-					Possession:     ts.Possession,
-					Yellow:         ts.Yellow,
-					Red:            ts.Red,
-					GKSaves:        ts.GkSaves,
-					PassesTotal:    ts.PassesTotal,
-					PassesAccurate: ts.PassesAccurate,
-					PassesPercent:  ts.PassesPercent,
-					ExpectedGoals:  ts.ExpectedGoals,
-				})
-				if err != nil {
-					fm.Logger.Err(err).Msg(fmt.Sprintf("insert team statistic: fixture_%d#team_%d", fd.Fixture.ID, l.Team.ID))
+				if b.coverage.StatisticsFixtures {
+					ts := convertTeamStatistics(i, &fd, fm.Logger)
+					b.teamStats = append(b.teamStats, &TeamStatisticsRow{
+						Team:           l.Team.ID,
+						Fixture:        fd.Fixture.ID,
+						ShotsTotal:     ts.ShotsTotal,
+						ShotsOn:        ts.ShotsOn,
+						ShotsOff:       ts.ShotsOff,
+						ShotsBlocked:   ts.ShotsBlocked,
+						ShotsBox:       ts.ShotsBox,
+						ShotsOutside:   ts.ShotsOutside,
+						Offsides:       ts.Offsides,
+						Fouls:          ts.Fouls,
+						Corners:        ts.Corners,
+						Possession:     ts.Possession,
+						Yellow:         ts.Yellow,
+						Red:            ts.Red,
+						GKSaves:        ts.GkSaves,
+						PassesTotal:    ts.PassesTotal,
+						PassesAccurate: ts.PassesAccurate,
+						PassesPercent:  ts.PassesPercent,
+						ExpectedGoals:  ts.ExpectedGoals,
+					})
 				}
 
-				if len(l.Substitutes) == 5 {
+				var err error
+				if b.coverage.Lineups && len(l.Substitutes) == 5 {
 
 					_, err = fm.Repo.InsertFormation(&FormationRow{
 						Fixture:   fd.Fixture.ID,
@@ -209,7 +368,7 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 						Sub5:      l.Substitutes[4].Player.ID,
 						Coach:     l.Coach.ID,
 					})
-				} else if len(l.Substitutes) == 4 {
+				} else if b.coverage.Lineups && len(l.Substitutes) == 4 {
 					_, err = fm.Repo.InsertFormation(&FormationRow{
 						Fixture:   fd.Fixture.ID,
 						Team:      l.Team.ID,
@@ -231,7 +390,7 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 						Sub4:      l.Substitutes[3].Player.ID,
 						Coach:     l.Coach.ID,
 					})
-				} else if len(l.Substitutes) == 3 {
+				} else if b.coverage.Lineups && len(l.Substitutes) == 3 {
 					_, err = fm.Repo.InsertFormation(&FormationRow{
 						Fixture:   fd.Fixture.ID,
 						Team:      l.Team.ID,
@@ -255,11 +414,14 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 				}
 				if err != nil {
 					fm.Logger.Err(err).Msg(fmt.Sprintf("insert formation: fixture_%d#team_%d", fd.Fixture.ID, l.Team.ID))
+					b.fail(fd.Fixture.ID, fmt.Errorf("insert formation: team_%d: %w", l.Team.ID, err))
 				}
 			}
 			for _, playerstats := range fd.Players {
-				// insert player statistics
-				t := playerstats.Team.ID
+				if !b.coverage.StatisticsPlayers {
+					break
+				}
+				// collect player statistics
 				for _, player := range playerstats.Players {
 					ps := player.Statistics[0]
 					defaultStringValue(&ps)
@@ -271,7 +433,7 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 					if err != nil {
 						fm.Logger.Err(err).Msg("")
 					}
-					_, err = fm.PlayerRepo.InsertStats(&players.PlayerStatsRow{
+					b.playerStats = append(b.playerStats, &players.PlayerStatsRow{
 						Player:           player.Player.ID,
 						Fixture:          fd.Fixture.ID,
 						Team:             playerstats.Team.ID,
@@ -305,24 +467,129 @@ func (fm *FixtureModel) InsertFixture(fr *[]FixtureDetail, league, season, round
 						PenaltySaved:     ps.Penalty.Saved,
 						Saves:            ps.Goals.Saves,
 					})
-
-					if err != nil && strings.HasSuffix(err.Error(), "(SQLSTATE 23503)") {
-						fm.Logger.Info().Msg(fmt.Sprintf("retrying player#%d", player.Player.ID))
-						addMissingPlayer(*fm.PlayerRepo, fm.Logger,
-							season, player.Player.ID, t, ps.Games.Rating)
-					} else if err != nil {
-						fm.Logger.Err(err).Msg(fmt.Sprintf(
-							"player statistics#player_%d#fixture_%d",
-							player.Player.ID,
-							fd.Fixture.ID))
-					}
 				}
 
 			}
 		}
+
+		if b.failed[fd.Fixture.ID] == nil {
+			b.changes = append(b.changes, change)
+		}
 	}
 }
 
+// ensureVenue writes the venue of fd unless it is known and returns its
+// id, nil for fixtures without a venue.
+func (fm *FixtureModel) ensureVenue(fd *FixtureDetail) *int {
+
+	v := fd.Fixture.Venue
+	if v.ID == 0 {
+		return nil
+	}
+	if fm.VenueRepo != nil {
+		_, err := fm.VenueRepo.InsertIfMissing(&team.VenueRow{Id: v.ID, Name: v.Name, City: v.City})
+		if err != nil {
+			fm.Logger.Err(err).Msg(fmt.Sprintf("insert venue: fixture_%d#venue_%d", fd.Fixture.ID, v.ID))
+		}
+	}
+	return &v.ID
+}
+
+// ensureReferee returns the id of the referee of fd, nil if there is none
+// or it could not be written.
+func (fm *FixtureModel) ensureReferee(fd *FixtureDetail) *int {
+
+	if fm.RefereeRepo == nil {
+		return nil
+	}
+	id, err := fm.RefereeRepo.Ensure(fd.Fixture.Referee)
+	if err != nil {
+		fm.Logger.Err(err).Msg(fmt.Sprintf("insert referee: fixture_%d#referee_%s", fd.Fixture.ID, fd.Fixture.Referee))
+		return nil
+	}
+	return id
+}
+
+// writeResults recomputes the results of both teams of fd, overwriting
+// those of an earlier version of the fixture.
+func (fm *FixtureModel) writeResults(fd *FixtureDetail, league, season, round int, run *shared.Throughput) error {
+
+	var errs error
+	home, away := calculateResult(fd, league, season, round)
+	for _, r := range []*result.ResultRow{home, away} {
+		n, err := fm.ResultRepo.Upsert(r)
+		if err != nil {
+			fm.Logger.Err(err).Msg(fmt.Sprintf("upsert result: fixture_%d#team_%d", fd.Fixture.ID, r.Team))
+			errs = errors.Join(errs, fmt.Errorf("upsert result: team_%d: %w", r.Team, err))
+		}
+		run.Add("results", n)
+	}
+	return errs
+}
+
+// flush writes the collected statistics in bulk, commits the versions of
+// the collected fixtures if all statistics were written and empties b.
+// Otherwise all collected fixtures are recorded as failed.
+func (fm *FixtureModel) flush(b *statsBatch, run *shared.Throughput) {
+
+	var errs error
+	n, err := fm.Repo.CopyTeamsStats(b.teamStats)
+	if err != nil {
+		fm.Logger.Err(err).Msg(fmt.Sprintf("copy team statistics: rows=%d", len(b.teamStats)))
+		errs = errors.Join(errs, fmt.Errorf("copy team statistics: %w", err))
+	}
+	run.Add("team_statistics", n)
+
+	n, missing, err := fm.PlayerRepo.CopyStats(b.playerStats)
+	if err != nil {
+		fm.Logger.Err(err).Msg(fmt.Sprintf("copy player statistics: rows=%d", len(b.playerStats)))
+		errs = errors.Join(errs, fmt.Errorf("copy player statistics: %w", err))
+	}
+	run.Add("player_statistics", n)
+
+	if len(missing) > 0 {
+		// rows of players the players endpoint did not return, add those
+		// players and copy their rows again
+		isMissing := make(map[int]bool, len(missing))
+		for _, id := range missing {
+			isMissing[id] = true
+		}
+		added := make(map[int]bool, len(missing))
+		var retry []*players.PlayerStatsRow
+		for _, ps := range b.playerStats {
+			if !isMissing[ps.Player] {
+				continue
+			}
+			if !added[ps.Player] {
+				added[ps.Player] = true
+				fm.Logger.Info().Msg(fmt.Sprintf("retrying player#%d", ps.Player))
+				err := addMissingPlayer(fm.Client, *fm.PlayerRepo, fm.Logger,
+					ps.Season, ps.Player, ps.Team, strconv.FormatFloat(ps.Rating, 'f', -1, 64))
+				if err != nil {
+					fm.Logger.Err(err).Msg(fmt.Sprintf("add missing player#%d", ps.Player))
+				}
+			}
+			retry = append(retry, ps)
+		}
+		n, _, err := fm.PlayerRepo.CopyStats(retry)
+		if err != nil {
+			fm.Logger.Err(err).Msg(fmt.Sprintf("copy player statistics: rows=%d", len(retry)))
+			errs = errors.Join(errs, fmt.Errorf("copy player statistics: %w", err))
+		}
+		run.Add("player_statistics", n)
+	}
+
+	if errs == nil {
+		fm.commit(b.changes)
+	} else {
+		fm.Logger.Error().Msg(fmt.Sprintf("statistics incomplete, fixtures are written again next update: fixtures=%d", len(b.changes)))
+		for _, c := range b.changes {
+			b.fail(c.ID, errs)
+		}
+	}
+	b.teamStats, b.playerStats, b.changes = nil, nil, nil
+}
+
 // uses goals to calculate win, draw, loss and adds the given points
 func calculateResult(fd *FixtureDetail, league, season, round int) (*result.ResultRow, *result.ResultRow) {
 	hPoints := 0
@@ -379,21 +646,19 @@ func defaultStringValue(ps *PlayerStatisticsDetailsFD) {
 // However the rapid api plaeyer endpoint is missing player entries.
 // If those appear during fixture insertion. Get the player detail and insert it
 // separately.
-func addMissingPlayer(repo players.Repo, logger *zerolog.Logger, season, id, team int, rating string) error {
-	p, err := players.GetPlayerById(id, season)
+func addMissingPlayer(c *comm.Client, repo players.Repo, logger *zerolog.Logger, season, id, team int, rating string) error {
+	p, err := players.GetPlayerById(c, id, season)
 	if err != nil {
 		return err
 	} else {
+		if _, err := repo.UpsertPerson(players.NewPerson(&p.PlayerDetails)); err != nil {
+			return err
+		}
 		_, err := repo.Insert(
 			&players.PlayerRow{
-				Id:           p.PlayerDetails.ID,
-				Team:         team,
-				Season:       season,
-				FirstName:    p.PlayerDetails.FirstName,
-				LastName:     p.PlayerDetails.LastName,
-				BirthPlace:   p.PlayerDetails.Birth.Place,
-				BirthCountry: p.PlayerDetails.Birth.Country,
-				BirthDate:    p.PlayerDetails.Birth.Date,
+				Id:     p.PlayerDetails.ID,
+				Team:   team,
+				Season: season,
 			},
 		)
 		if err != nil {
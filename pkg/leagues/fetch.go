@@ -59,9 +59,9 @@ type LeagueData struct {
 	Seasons []Season `json:"seasons"`
 }
 
-func GetLeagues() (*LeagueResponse, error) {
+func GetLeagues(c *comm.Client) (*LeagueResponse, error) {
 
-	data, err := comm.GetHttpBody(getLeaguesURL)
+	data, err := c.GetHttpBody(getLeaguesURL)
 	if err != nil {
 		return nil, err
 	}
@@ -75,9 +75,9 @@ func GetLeagues() (*LeagueResponse, error) {
 	return l, nil
 }
 
-func GetLeague(id int) (*LeagueResponse, error) {
+func GetLeague(c *comm.Client, id int) (*LeagueResponse, error) {
 
-	data, err := comm.GetHttpBody(fmt.Sprintf(getLeagueURL, id))
+	data, err := c.GetHttpBody(fmt.Sprintf(getLeagueURL, id))
 	if err != nil {
 		return nil, err
 	}
@@ -153,9 +153,9 @@ type StandingsGoalStats struct {
 	Against int `json:"against"`
 }
 
-func GetStanding(league int, season int) (*StandingsEntry, error) {
+func GetStanding(c *comm.Client, league int, season int) (*StandingsEntry, error) {
 
-	data, err := comm.GetHttpBody(getStandingURL, league, season)
+	data, err := c.GetHttpBody(getStandingURL, league, season)
 	if err != nil {
 		return nil, err
 	}
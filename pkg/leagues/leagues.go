@@ -1,27 +1,39 @@
 package leagues
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 type LeaguesModel struct {
 	Logger *zerolog.Logger
+	Client *comm.Client
 	Repo   interface {
 		Insert(*League) (int64, error)
+		InsertSeasons(int, []Season) (int64, error)
+		Select(int) (*LeagueRow, error)
+		SelectLeagues(string, string, shared.PageRequest) (*shared.Page[LeagueRow], error)
+		SelectSeasons(int) ([]*SeasonRow, error)
+		SelectCoverage(int, int) (*SeasonCoverage, error)
 	}
 }
 
 func (lm *LeaguesModel) FetchAndInsertLeagues() (*[]int, error) {
-	ls, err := GetLeagues()
+	ls, err := GetLeagues(lm.Client)
 	var failed []int
 	if err != nil {
 		log.Err(err).Msg("")
+		return &failed, err
 	}
 
 	for _, l := range ls.Response {
-		_, err := lm.Repo.Insert(&l.League)
-		if err != nil {
+		if err := lm.insert(&l); err != nil {
 			failed = append(failed, l.League.ID)
 		}
 	}
@@ -29,17 +41,52 @@ func (lm *LeaguesModel) FetchAndInsertLeagues() (*[]int, error) {
 }
 
 func (lm *LeaguesModel) FetchAndInsertLeague(league int) (*LeagueResponse, *[]int, error) {
-	ls, err := GetLeague(league)
+	ls, err := GetLeague(lm.Client, league)
 	var failed []int
 	if err != nil {
 		log.Err(err).Msg("")
+		return nil, &failed, err
 	}
 
 	for _, l := range ls.Response {
-		_, err := lm.Repo.Insert(&l.League)
-		if err != nil {
+		if err := lm.insert(&l); err != nil {
 			failed = append(failed, l.League.ID)
 		}
 	}
 	return ls, &failed, nil
 }
+
+// insert writes a league with its country and seasons. The league object
+// of the leagues endpoint leaves country and flag empty, they come with
+// the country object.
+func (lm *LeaguesModel) insert(l *LeagueData) error {
+
+	l.League.Country, l.League.Flag = l.Country.Name, l.Country.Flag
+	if _, err := lm.Repo.Insert(&l.League); err != nil {
+		lm.Logger.Err(err).Msg(fmt.Sprintf("insert league: league=%d", l.League.ID))
+		return err
+	}
+	if _, err := lm.Repo.InsertSeasons(l.League.ID, l.Seasons); err != nil {
+		lm.Logger.Err(err).Msg(fmt.Sprintf("insert seasons: league=%d", l.League.ID))
+		return err
+	}
+	return nil
+}
+
+// Coverage returns what the provider covers for a league season. Seasons
+// without stored coverage are assumed to be fully covered. If the coverage
+// can't be read nothing is assumed to be covered.
+func (lm *LeaguesModel) Coverage(league, season int) *SeasonCoverage {
+
+	c, err := lm.Repo.SelectCoverage(league, season)
+	if errors.Is(err, pgx.ErrNoRows) {
+		lm.Logger.Debug().Msg(fmt.Sprintf("coverage: league=%d#season=%d#no stored coverage", league, season))
+		full := FullCoverage
+		return &full
+	}
+	if err != nil {
+		lm.Logger.Err(err).Msg(fmt.Sprintf("coverage: league=%d#season=%d", league, season))
+		return &SeasonCoverage{}
+	}
+	return c
+}
@@ -3,11 +3,27 @@ package leagues
 import (
 	"context"
 
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	insertLeague = `INSERT INTO leagues (id, name) VALUES ($1, $2);`
+	insertLeague = `INSERT INTO leagues (id, name, type, country, logo, flag) VALUES ($1, $2, $3, $4, $5, $6)
+						ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, type = EXCLUDED.type, country = EXCLUDED.country,
+						logo = EXCLUDED.logo, flag = EXCLUDED.flag;`
+	insertSeason = `INSERT INTO league_seasons (league, season, start_date, end_date, current, coverage)
+						VALUES ($1, $2, $3::date, $4::date, $5, $6)
+						ON CONFLICT (league, season) DO UPDATE SET start_date = EXCLUDED.start_date, end_date = EXCLUDED.end_date,
+						current = EXCLUDED.current, coverage = EXCLUDED.coverage;`
+	selectLeague = `SELECT id, coalesce(name, '') AS name, coalesce(type, '') AS type, coalesce(country, '') AS country,
+						coalesce(logo, '') AS logo, coalesce(flag, '') AS flag FROM leagues WHERE id = $1`
+	fromLeagues   = `leagues WHERE ($1 = '' OR country = $1) AND ($2 = '' OR type = $2)`
+	countLeagues  = `SELECT count(*) FROM leagues WHERE ($1 = '' OR country = $1) AND ($2 = '' OR type = $2)`
+	selectSeasons = `SELECT league, season, coalesce(to_char(start_date, 'YYYY-MM-DD'), '') AS start,
+						coalesce(to_char(end_date, 'YYYY-MM-DD'), '') AS "end", current, coverage
+						FROM league_seasons WHERE league = $1 ORDER BY season DESC`
+	selectCoverage = `SELECT coverage FROM league_seasons WHERE league = $1 AND season = $2`
 )
 
 type LeagueRepo struct {
@@ -33,15 +49,144 @@ type SeasonCoverageFixtures struct {
 	StatisticsPlayers  bool `json:"statistics_players"`
 }
 
+// FullCoverage is assumed for seasons without stored coverage.
+var FullCoverage = SeasonCoverage{
+	Fixtures:    SeasonCoverageFixtures{Events: true, Lineups: true, StatisticsFixtures: true, StatisticsPlayers: true},
+	Standings:   true,
+	Players:     true,
+	TopScorers:  true,
+	TopAssists:  true,
+	TopCards:    true,
+	Injuries:    true,
+	Predictions: true,
+	Odds:        true,
+}
+
 type StandingsPaging struct {
 	Current int `json:"current"`
 	Total   int `json:"total"`
 }
 
+type LeagueRow struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Country string `json:"country"`
+	Logo    string `json:"logo"`
+	Flag    string `json:"flag"`
+}
+
+// SeasonRow is a season of a league with the data the provider covers.
+type SeasonRow struct {
+	League   int             `json:"league"`
+	Season   int             `json:"season"`
+	Start    string          `json:"start"`
+	End      string          `json:"end"`
+	Current  bool            `json:"current"`
+	Coverage *SeasonCoverage `json:"coverage"`
+}
+
+// LeagueColumns are the fields of league lists by their json name.
+var LeagueColumns = shared.Columns{
+	"id":      {Expr: "id", Type: "int4"},
+	"name":    {Expr: "coalesce(name, '')", Type: "text"},
+	"type":    {Expr: "coalesce(type, '')", Type: "text"},
+	"country": {Expr: "coalesce(country, '')", Type: "text"},
+	"logo":    {Expr: "coalesce(logo, '')", Type: "text"},
+	"flag":    {Expr: "coalesce(flag, '')", Type: "text"},
+}
+
 func (lm *LeagueRepo) Insert(l *League) (int64, error) {
 	row, err := lm.Pool.Exec(
 		context.Background(),
 		insertLeague,
-		l.ID, l.Name)
+		l.ID, l.Name, l.Type, l.Country, l.Logo, l.Flag)
 	return row.RowsAffected(), err
 }
+
+// InsertSeasons upserts the seasons of a league in one batch.
+func (lm *LeagueRepo) InsertSeasons(league int, seasons []Season) (int64, error) {
+
+	b := &pgx.Batch{}
+	for _, s := range seasons {
+		b.Queue(insertSeason, league, s.Year, nullDate(s.Start), nullDate(s.End), s.Current, s.Coverage)
+	}
+
+	var total int64
+	br := lm.Pool.SendBatch(context.Background(), b)
+	defer br.Close()
+	for range seasons {
+		tag, err := br.Exec()
+		if err != nil {
+			return total, err
+		}
+		total += tag.RowsAffected()
+	}
+	return total, br.Close()
+}
+
+func (lm *LeagueRepo) Select(id int) (*LeagueRow, error) {
+
+	rows, err := lm.Pool.Query(context.Background(), selectLeague, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[LeagueRow])
+}
+
+// SelectLeagues pages through the leagues, country and type filter them
+// unless empty.
+func (lm *LeagueRepo) SelectLeagues(country, typ string, page shared.PageRequest) (*shared.Page[LeagueRow], error) {
+
+	q, err := LeagueColumns.Query(page, []string{"id"}, []interface{}{country, typ})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := lm.Pool.Query(context.Background(), q.Statement(fromLeagues), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leagues, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[LeagueRow])
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := lm.Pool.QueryRow(context.Background(), countLeagues, country, typ).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	return shared.Paginate(q, leagues, &total)
+}
+
+// SelectSeasons returns the seasons of a league, latest first.
+func (lm *LeagueRepo) SelectSeasons(league int) ([]*SeasonRow, error) {
+
+	rows, err := lm.Pool.Query(context.Background(), selectSeasons, league)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[SeasonRow])
+}
+
+// SelectCoverage returns what the provider covers for a league season,
+// pgx.ErrNoRows if the season is unknown.
+func (lm *LeagueRepo) SelectCoverage(league, season int) (*SeasonCoverage, error) {
+
+	c := &SeasonCoverage{}
+	err := lm.Pool.QueryRow(context.Background(), selectCoverage, league, season).Scan(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func nullDate(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+// Package metrics turns summed match statistics of players into per 90
+// minutes values and success rates. Summing first weighs every
+// appearance by its minutes, a cameo counts for less than a full match.
+package metrics
+
+import "math"
+
+// Z95 is the standard normal quantile of 95% confidence intervals.
+const Z95 = 1.959964
+
+// TotalsColumns selects the columns of Totals from player_statistics
+// aliased ps, grouped by the caller.
+const TotalsColumns = `count(*)::int AS appearances,
+	coalesce(sum(ps.minutes), 0)::int AS minutes,
+	coalesce(sum(ps.goals_scored), 0)::int AS goals,
+	coalesce(sum(ps.goals_assisted), 0)::int AS assists,
+	coalesce(sum(ps.shots_total), 0)::int AS shots_total,
+	coalesce(sum(ps.shots_on), 0)::int AS shots_on,
+	coalesce(sum(ps.passes_key), 0)::int AS key_passes,
+	coalesce(sum(ps.tackles), 0)::int AS tackles,
+	coalesce(sum(ps.interceptions), 0)::int AS interceptions,
+	coalesce(sum(ps.duels_total), 0)::int AS duels_total,
+	coalesce(sum(ps.duels_won), 0)::int AS duels_won,
+	coalesce(sum(ps.dribbles_total), 0)::int AS dribbles_total,
+	coalesce(sum(ps.dribbles_won), 0)::int AS dribbles_won,
+	coalesce(sum(ps.minutes) FILTER (WHERE ps.rating > 0), 0)::int AS rated_minutes,
+	coalesce(sum(ps.rating * ps.minutes) FILTER (WHERE ps.rating > 0), 0)::float8 AS rating_minutes`
+
+// Totals sums the match statistics of a player. RatingMinutes sums the
+// ratings weighted by minutes over the RatedMinutes of rated appearances.
+type Totals struct {
+	Appearances   int     `json:"appearances"`
+	Minutes       int     `json:"minutes"`
+	Goals         int     `json:"goals"`
+	Assists       int     `json:"assists"`
+	ShotsTotal    int     `json:"shots_total"`
+	ShotsOn       int     `json:"shots_on"`
+	KeyPasses     int     `json:"key_passes"`
+	Tackles       int     `json:"tackles"`
+	Interceptions int     `json:"interceptions"`
+	DuelsTotal    int     `json:"duels_total"`
+	DuelsWon      int     `json:"duels_won"`
+	DribblesTotal int     `json:"dribbles_total"`
+	DribblesWon   int     `json:"dribbles_won"`
+	RatedMinutes  int     `json:"rated_minutes"`
+	RatingMinutes float64 `json:"-"`
+}
+
+// Per90 holds values per 90 minutes played.
+type Per90 struct {
+	Goals         float64 `json:"goals"`
+	Assists       float64 `json:"assists"`
+	Shots         float64 `json:"shots"`
+	ShotsOn       float64 `json:"shots_on"`
+	KeyPasses     float64 `json:"key_passes"`
+	Tackles       float64 `json:"tackles"`
+	Interceptions float64 `json:"interceptions"`
+	Duels         float64 `json:"duels"`
+	DuelsWon      float64 `json:"duels_won"`
+	Dribbles      float64 `json:"dribbles"`
+	DribblesWon   float64 `json:"dribbles_won"`
+}
+
+// Rate is a success rate with its Wilson score interval. Small samples
+// get wide intervals, Value and both bounds are 0 without attempts.
+type Rate struct {
+	Successes int     `json:"successes"`
+	Attempts  int     `json:"attempts"`
+	Value     float64 `json:"value"`
+	Lower     float64 `json:"lower"`
+	Upper     float64 `json:"upper"`
+}
+
+// Rates are the success rates of a player. Conversion is goals per shot.
+type Rates struct {
+	Duels        Rate `json:"duels"`
+	Dribbles     Rate `json:"dribbles"`
+	ShotAccuracy Rate `json:"shot_accuracy"`
+	Conversion   Rate `json:"conversion"`
+}
+
+// Metrics are the per 90 values and success rates of a player. Rating
+// is weighted by minutes and nil without rated appearances.
+type Metrics struct {
+	Appearances int      `json:"appearances"`
+	Minutes     int      `json:"minutes"`
+	Rating      *float64 `json:"rating"`
+	Per90       Per90    `json:"per90"`
+	Rates       Rates    `json:"rates"`
+}
+
+// PerNinety scales a total over minutes to 90 minutes, 0 without minutes.
+func PerNinety(total, minutes int) float64 {
+	if minutes <= 0 {
+		return 0
+	}
+	return float64(total) * 90 / float64(minutes)
+}
+
+// Wilson returns the Wilson score interval of successes in attempts for
+// the normal quantile z.
+func Wilson(successes, attempts int, z float64) (float64, float64) {
+
+	if attempts <= 0 {
+		return 0, 0
+	}
+	n := float64(attempts)
+	p := float64(successes) / n
+	z2 := z * z
+	centre := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return math.Max(0, centre-margin), math.Min(1, centre+margin)
+}
+
+// NewRate returns the rate of successes in attempts with its 95%
+// interval. Successes are capped at attempts, the provider occasionally
+// counts more goals than shots.
+func NewRate(successes, attempts int) Rate {
+
+	if successes > attempts {
+		successes = attempts
+	}
+	r := Rate{Successes: successes, Attempts: attempts}
+	if attempts > 0 {
+		r.Value = float64(successes) / float64(attempts)
+		r.Lower, r.Upper = Wilson(successes, attempts, Z95)
+	}
+	return r
+}
+
+// Rating returns the minutes weighted rating, nil without rated minutes.
+func (t *Totals) Rating() *float64 {
+	if t.RatedMinutes <= 0 {
+		return nil
+	}
+	r := t.RatingMinutes / float64(t.RatedMinutes)
+	return &r
+}
+
+// Metrics computes the per 90 values and success rates of t.
+func (t *Totals) Metrics() *Metrics {
+
+	return &Metrics{
+		Appearances: t.Appearances,
+		Minutes:     t.Minutes,
+		Rating:      t.Rating(),
+		Per90: Per90{
+			Goals:         PerNinety(t.Goals, t.Minutes),
+			Assists:       PerNinety(t.Assists, t.Minutes),
+			Shots:         PerNinety(t.ShotsTotal, t.Minutes),
+			ShotsOn:       PerNinety(t.ShotsOn, t.Minutes),
+			KeyPasses:     PerNinety(t.KeyPasses, t.Minutes),
+			Tackles:       PerNinety(t.Tackles, t.Minutes),
+			Interceptions: PerNinety(t.Interceptions, t.Minutes),
+			Duels:         PerNinety(t.DuelsTotal, t.Minutes),
+			DuelsWon:      PerNinety(t.DuelsWon, t.Minutes),
+			Dribbles:      PerNinety(t.DribblesTotal, t.Minutes),
+			DribblesWon:   PerNinety(t.DribblesWon, t.Minutes),
+		},
+		Rates: Rates{
+			Duels:        NewRate(t.DuelsWon, t.DuelsTotal),
+			Dribbles:     NewRate(t.DribblesWon, t.DribblesTotal),
+			ShotAccuracy: NewRate(t.ShotsOn, t.ShotsTotal),
+			Conversion:   NewRate(t.Goals, t.ShotsTotal),
+		},
+	}
+}
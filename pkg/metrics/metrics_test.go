@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+// near reports whether a and b agree to 4 decimals.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-4
+}
+
+func TestWilson(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		successes int
+		attempts  int
+		lower     float64
+		upper     float64
+	}{
+		{name: "no attempts", successes: 0, attempts: 0, lower: 0, upper: 0},
+		{name: "half of ten", successes: 5, attempts: 10, lower: 0.2366, upper: 0.7634},
+		{name: "none of ten", successes: 0, attempts: 10, lower: 0, upper: 0.2775},
+		{name: "all of ten", successes: 10, attempts: 10, lower: 0.7225, upper: 1},
+		{name: "a single success", successes: 1, attempts: 1, lower: 0.2065, upper: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lower, upper := Wilson(tt.successes, tt.attempts, Z95)
+			if !near(lower, tt.lower) || !near(upper, tt.upper) {
+				t.Errorf("Wilson(%d, %d) = %.4f, %.4f, want %.4f, %.4f",
+					tt.successes, tt.attempts, lower, upper, tt.lower, tt.upper)
+			}
+			if lower < 0 || upper > 1 || lower > upper {
+				t.Errorf("Wilson(%d, %d) = %v, %v is not an interval in [0, 1]", tt.successes, tt.attempts, lower, upper)
+			}
+		})
+	}
+}
+
+func TestNewRate(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		successes int
+		attempts  int
+		want      Rate
+	}{
+		{
+			name: "no attempts",
+			want: Rate{},
+		},
+		{
+			name:      "no successes",
+			successes: 0,
+			attempts:  10,
+			want:      Rate{Successes: 0, Attempts: 10, Value: 0, Lower: 0, Upper: 0.2775},
+		},
+		{
+			name:      "all successes",
+			successes: 10,
+			attempts:  10,
+			want:      Rate{Successes: 10, Attempts: 10, Value: 1, Lower: 0.7225, Upper: 1},
+		},
+		{
+			name:      "successes are capped at attempts",
+			successes: 12,
+			attempts:  10,
+			want:      Rate{Successes: 10, Attempts: 10, Value: 1, Lower: 0.7225, Upper: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewRate(tt.successes, tt.attempts)
+			if got.Successes != tt.want.Successes || got.Attempts != tt.want.Attempts ||
+				!near(got.Value, tt.want.Value) || !near(got.Lower, tt.want.Lower) || !near(got.Upper, tt.want.Upper) {
+				t.Errorf("NewRate(%d, %d) = %+v, want %+v", tt.successes, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerNinety(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		total   int
+		minutes int
+		want    float64
+	}{
+		{name: "no minutes", total: 3, minutes: 0, want: 0},
+		{name: "negative minutes", total: 3, minutes: -90, want: 0},
+		{name: "a full match", total: 2, minutes: 90, want: 2},
+		{name: "half a match", total: 1, minutes: 45, want: 2},
+		{name: "several matches", total: 3, minutes: 270, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PerNinety(tt.total, tt.minutes); !near(got, tt.want) {
+				t.Errorf("PerNinety(%d, %d) = %v, want %v", tt.total, tt.minutes, got, tt.want)
+			}
+		})
+	}
+}
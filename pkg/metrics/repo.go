@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// team is the one the player appeared for last
+	selectPlayerTotals = `SELECT ps.player, coalesce(pe.name, '') AS name,
+							(array_agg(ps.team ORDER BY f.timestamp DESC))[1] AS team, ps.league, ps.season, ` + TotalsColumns + `
+						FROM player_statistics ps
+						JOIN fixtures f ON f.id = ps.fixture
+						LEFT JOIN people pe ON pe.id = ps.player
+						WHERE ps.player = $1 AND ps.league = $2 AND ps.season = $3 AND ps.minutes > 0
+						GROUP BY ps.player, pe.name, ps.league, ps.season`
+	selectTeamTotals = `SELECT ps.player, coalesce(pe.name, '') AS name, ps.team, ps.league, ps.season, ` + TotalsColumns + `
+						FROM player_statistics ps
+						LEFT JOIN people pe ON pe.id = ps.player
+						WHERE ps.team = $1 AND ps.league = $2 AND ps.season = $3 AND ps.minutes > 0
+						GROUP BY ps.player, pe.name, ps.team, ps.league, ps.season
+						ORDER BY minutes DESC, ps.player`
+)
+
+type Repo struct {
+	Pool *pgxpool.Pool
+}
+
+// PlayerRow holds the totals and metrics of a player in a league season.
+type PlayerRow struct {
+	Player  int    `json:"player"`
+	Name    string `json:"name"`
+	Team    int    `json:"team"`
+	League  int    `json:"league"`
+	Season  int    `json:"season"`
+	Totals  `json:"totals"`
+	Metrics *Metrics `json:"metrics" db:"-"`
+}
+
+// SelectPlayer returns the metrics of a player in a league season,
+// pgx.ErrNoRows if the player did not play in it.
+func (repo *Repo) SelectPlayer(player, league, season int) (*PlayerRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectPlayerTotals, player, league, season)
+	if err != nil {
+		return nil, err
+	}
+	p, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[PlayerRow])
+	if err != nil {
+		return nil, err
+	}
+	p.Metrics = p.Totals.Metrics()
+	return p, nil
+}
+
+// SelectTeam returns the metrics of the players of a team in a league
+// season, most minutes first.
+func (repo *Repo) SelectTeam(team, league, season int) ([]*PlayerRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectTeamTotals, team, league, season)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[PlayerRow])
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		p.Metrics = p.Totals.Metrics()
+	}
+	return ps, nil
+}
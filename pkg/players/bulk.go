@@ -0,0 +1,123 @@
+package players
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	createPlayerStatisticsStaging = `CREATE TEMP TABLE player_statistics_staging (LIKE player_statistics) ON COMMIT DROP`
+	selectMissingStagedPlayers    = `SELECT DISTINCT s.player FROM player_statistics_staging s WHERE NOT EXISTS (SELECT 1 FROM players p WHERE p.id = s.player)`
+	mergePlayerStatistics         = `INSERT INTO player_statistics SELECT s.* FROM player_statistics_staging s WHERE EXISTS (SELECT 1 FROM players p WHERE p.id = s.player) ON CONFLICT DO NOTHING`
+)
+
+// columns of player_statistics in the order of PlayerStatsRow.values
+var playerStatisticsColumns = []string{
+	"player", "fixture", "team", "league", "season", "minutes", "position", "rating", "captain", "substitute",
+	"shots_total", "shots_on", "goals_scored", "goals_assisted", "passes_total", "passes_key", "accuracy",
+	"tackles", "block", "interceptions", "duels_total", "duels_won", "dribbles_total", "dribbles_won",
+	"yellow", "red", "penalty_won", "penalty_committed", "penalty_scored", "penalty_missed", "penalty_saved", "saves",
+}
+
+// CopyStats writes player statistics with COPY into a staging table and
+// merges them in one statement. Rows of players missing from the players
+// table are not merged, their ids are returned so the caller can add the
+// players and copy those rows again. Rows already present are skipped.
+func (pm *Repo) CopyStats(rows []*PlayerStatsRow) (int64, []int, error) {
+
+	if len(rows) == 0 {
+		return 0, nil, nil
+	}
+
+	ctx := context.Background()
+	tx, err := pm.Pool.Begin(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, createPlayerStatisticsStaging); err != nil {
+		return 0, nil, err
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"player_statistics_staging"}, playerStatisticsColumns,
+		pgx.CopyFromSlice(len(rows), func(i int) ([]interface{}, error) {
+			return rows[i].values(), nil
+		}))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	missingRows, err := tx.Query(ctx, selectMissingStagedPlayers)
+	if err != nil {
+		return 0, nil, err
+	}
+	missing, err := pgx.CollectRows(missingRows, pgx.RowTo[int])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tag, err := tx.Exec(ctx, mergePlayerStatistics)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return tag.RowsAffected(), missing, tx.Commit(ctx)
+}
+
+// InsertBatch inserts players in one round trip, players already present
+// are skipped. The batch runs as one transaction, on error nothing is
+// written.
+func (pm *Repo) InsertBatch(ps []*PlayerRow) (int64, error) {
+
+	b := &pgx.Batch{}
+	for _, p := range ps {
+		b.Queue(insertPlayer+onConflictDoNothing, p.values()...)
+	}
+	return pm.sendBatch(b)
+}
+
+// InsertSeasonStatsBatch inserts season statistics in one round trip, rows
+// already present are skipped. The batch runs as one transaction, on error
+// nothing is written.
+func (pm *Repo) InsertSeasonStatsBatch(rows []*PlayerSeasonStatsRow) (int64, error) {
+
+	b := &pgx.Batch{}
+	for _, s := range rows {
+		b.Queue(insertPlayerStatisticsSeason+onConflictDoNothing, s.values()...)
+	}
+	return pm.sendBatch(b)
+}
+
+const onConflictDoNothing = ` ON CONFLICT DO NOTHING`
+
+func (pm *Repo) sendBatch(b *pgx.Batch) (int64, error) {
+
+	if b.Len() == 0 {
+		return 0, nil
+	}
+
+	ctx := context.Background()
+	tx, err := pm.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	res := tx.SendBatch(ctx, b)
+	var n int64
+	for i := 0; i < b.Len(); i++ {
+		tag, err := res.Exec()
+		if err != nil {
+			res.Close()
+			return 0, err
+		}
+		n += tag.RowsAffected()
+	}
+	if err := res.Close(); err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit(ctx)
+}
@@ -0,0 +1,341 @@
+package players
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bernhardson/prefoot/pkg/metrics"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// league 0 selects all competitions of the season
+	selectFormSeries = `SELECT ps.fixture, f.timestamp, ps.team, f.league,
+							CASE WHEN f.home_team = ps.team THEN f.away_team ELSE f.home_team END AS opponent,
+							f.home_team = ps.team AS home,
+							coalesce(ps.position, '') AS position,
+							ps.minutes,
+							CASE WHEN ps.rating > 0 THEN ps.rating END AS rating,
+							coalesce(ps.goals_scored, 0) AS goals,
+							coalesce(ps.goals_assisted, 0) AS assists,
+							coalesce(CASE WHEN f.home_team = ps.team THEN f.away_goals ELSE f.home_goals END, 0) AS conceded
+						FROM player_statistics ps
+						JOIN fixtures f ON f.id = ps.fixture
+						WHERE ps.player = $1 AND ($2 = 0 OR ps.league = $2) AND ps.season = $3 AND ps.minutes > 0
+						ORDER BY f.timestamp, f.id`
+	// the last $3 appearances of every player against their whole season,
+	// ratings weighted by minutes
+	selectInForm = `WITH a AS (
+						SELECT ps.player, ps.team, ps.minutes, ps.rating, ps.goals_scored, ps.goals_assisted, f.timestamp,
+							row_number() OVER (PARTITION BY ps.player ORDER BY f.timestamp DESC, f.id DESC) AS n
+						FROM player_statistics ps
+						JOIN fixtures f ON f.id = ps.fixture
+						WHERE ps.league = $1 AND ps.season = $2 AND ps.minutes > 0
+					)
+					SELECT a.player, coalesce(pe.name, '') AS name,
+						(array_agg(a.team ORDER BY a.n))[1] AS team,
+						count(*) FILTER (WHERE a.n <= $3)::int AS appearances,
+						coalesce(sum(a.minutes) FILTER (WHERE a.n <= $3), 0)::int AS minutes,
+						coalesce(sum(a.goals_scored) FILTER (WHERE a.n <= $3), 0)::int AS goals,
+						coalesce(sum(a.goals_assisted) FILTER (WHERE a.n <= $3), 0)::int AS assists,
+						(sum(a.rating * a.minutes) FILTER (WHERE a.n <= $3 AND a.rating > 0)
+							/ nullif(sum(a.minutes) FILTER (WHERE a.n <= $3 AND a.rating > 0), 0))::float8 AS rating,
+						(sum(a.rating * a.minutes) FILTER (WHERE a.rating > 0)
+							/ nullif(sum(a.minutes) FILTER (WHERE a.rating > 0), 0))::float8 AS season_rating,
+						max(a.timestamp) AS last_played
+					FROM a LEFT JOIN people pe ON pe.id = a.player
+					GROUP BY a.player, pe.name`
+)
+
+// orders of in form lists
+const (
+	FormByRating        = "rating"
+	FormByDelta         = "delta"
+	FormByContributions = "contributions"
+)
+
+// goalkeepers' position in player_statistics
+const positionGoalkeeper = "G"
+
+// minutes a goalkeeper plays at least to be credited with a clean sheet,
+// Conceded counts the goals of the whole match
+const cleanSheetMinutes = 60
+
+// FormPointRow is an appearance of a player. Rating is nil for unrated
+// appearances, Conceded counts the goals the player's team conceded.
+type FormPointRow struct {
+	Fixture   int      `json:"fixture"`
+	Timestamp int      `json:"timestamp"`
+	Team      int      `json:"team"`
+	League    int      `json:"league"`
+	Opponent  int      `json:"opponent"`
+	Home      bool     `json:"home"`
+	Position  string   `json:"position"`
+	Minutes   int      `json:"minutes"`
+	Rating    *float64 `json:"rating"`
+	Goals     int      `json:"goals"`
+	Assists   int      `json:"assists"`
+	Conceded  int      `json:"conceded"`
+}
+
+// FormSeries holds one value per appearance for each series, all of
+// the same length as Timestamps so they can be plotted directly. The
+// rolling series average the last Window appearances up to and including
+// each one, RatingAvg weighted by minutes and the per 90 series over the
+// minutes of the window.
+type FormSeries struct {
+	Timestamps   []int      `json:"timestamps"`
+	Fixtures     []int      `json:"fixtures"`
+	Opponents    []int      `json:"opponents"`
+	Rating       []*float64 `json:"rating"`
+	Minutes      []int      `json:"minutes"`
+	Goals        []int      `json:"goals"`
+	Assists      []int      `json:"assists"`
+	RatingAvg    []*float64 `json:"rating_avg"`
+	MinutesAvg   []float64  `json:"minutes_avg"`
+	GoalsPer90   []float64  `json:"goals_per90"`
+	AssistsPer90 []float64  `json:"assists_per90"`
+}
+
+// Streak is a run of consecutive appearances, First and Last are its
+// fixtures. Streaks of length 0 have neither.
+type Streak struct {
+	Length int `json:"length"`
+	First  int `json:"first,omitempty"`
+	Last   int `json:"last,omitempty"`
+	From   int `json:"from,omitempty"`
+	To     int `json:"to,omitempty"`
+}
+
+// StreakSummary holds the run still going at the last appearance and the
+// longest of the season.
+type StreakSummary struct {
+	Current Streak `json:"current"`
+	Longest Streak `json:"longest"`
+}
+
+// Streaks of a player. Clean sheets are only counted for goalkeepers and
+// nil for everyone else, an appearance is a clean sheet if the keeper
+// played cleanSheetMinutes and the team conceded nothing.
+type Streaks struct {
+	Scoring     StreakSummary  `json:"scoring"`
+	CleanSheets *StreakSummary `json:"clean_sheets,omitempty"`
+}
+
+// Form is the form of a player in a season, of one league or all
+// competitions if League is 0.
+type Form struct {
+	Player  int        `json:"player"`
+	League  int        `json:"league"`
+	Season  int        `json:"season"`
+	Window  int        `json:"window"`
+	Series  FormSeries `json:"series"`
+	Streaks Streaks    `json:"streaks"`
+}
+
+// InFormRow compares the last appearances of a player with the season.
+// Rating and SeasonRating are nil without rated appearances.
+type InFormRow struct {
+	Player       int      `json:"player"`
+	Name         string   `json:"name"`
+	Team         int      `json:"team"`
+	Appearances  int      `json:"appearances"`
+	Minutes      int      `json:"minutes"`
+	Goals        int      `json:"goals"`
+	Assists      int      `json:"assists"`
+	Rating       *float64 `json:"rating"`
+	SeasonRating *float64 `json:"season_rating"`
+	LastPlayed   int      `json:"last_played"`
+
+	Delta         float64 `json:"delta" db:"-"`
+	Contributions int     `json:"contributions" db:"-"`
+}
+
+// SelectFormSeries returns the appearances of a player in a season,
+// ordered by kick-off.
+func (repo *Repo) SelectFormSeries(player, league, season int) ([]*FormPointRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectFormSeries, player, league, season)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[FormPointRow])
+}
+
+// SelectInForm compares the last window appearances of every player of a
+// league season with their whole season.
+func (repo *Repo) SelectInForm(league, season, window int) ([]*InFormRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectInForm, league, season, window)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[InFormRow])
+}
+
+// Form builds the form series and streaks of a player over a season, the
+// rolling series average window appearances. Players without appearances
+// return pgx.ErrNoRows.
+func (pm *PlayerModel) Form(player, league, season, window int) (*Form, error) {
+
+	points, err := pm.Repo.SelectFormSeries(player, league, season)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return &Form{
+		Player:  player,
+		League:  league,
+		Season:  season,
+		Window:  window,
+		Series:  series(points, window),
+		Streaks: streaks(points),
+	}, nil
+}
+
+func series(points []*FormPointRow, window int) FormSeries {
+
+	n := len(points)
+	s := FormSeries{
+		Timestamps:   make([]int, 0, n),
+		Fixtures:     make([]int, 0, n),
+		Opponents:    make([]int, 0, n),
+		Rating:       make([]*float64, 0, n),
+		Minutes:      make([]int, 0, n),
+		Goals:        make([]int, 0, n),
+		Assists:      make([]int, 0, n),
+		RatingAvg:    make([]*float64, 0, n),
+		MinutesAvg:   make([]float64, 0, n),
+		GoalsPer90:   make([]float64, 0, n),
+		AssistsPer90: make([]float64, 0, n),
+	}
+
+	for i, p := range points {
+		s.Timestamps = append(s.Timestamps, p.Timestamp)
+		s.Fixtures = append(s.Fixtures, p.Fixture)
+		s.Opponents = append(s.Opponents, p.Opponent)
+		s.Rating = append(s.Rating, p.Rating)
+		s.Minutes = append(s.Minutes, p.Minutes)
+		s.Goals = append(s.Goals, p.Goals)
+		s.Assists = append(s.Assists, p.Assists)
+
+		from := i + 1 - window
+		if from < 0 {
+			from = 0
+		}
+		var t metrics.Totals
+		for _, w := range points[from : i+1] {
+			t.Appearances++
+			t.Minutes += w.Minutes
+			t.Goals += w.Goals
+			t.Assists += w.Assists
+			if w.Rating != nil {
+				t.RatedMinutes += w.Minutes
+				t.RatingMinutes += *w.Rating * float64(w.Minutes)
+			}
+		}
+		s.RatingAvg = append(s.RatingAvg, t.Rating())
+		s.MinutesAvg = append(s.MinutesAvg, float64(t.Minutes)/float64(t.Appearances))
+		s.GoalsPer90 = append(s.GoalsPer90, metrics.PerNinety(t.Goals, t.Minutes))
+		s.AssistsPer90 = append(s.AssistsPer90, metrics.PerNinety(t.Assists, t.Minutes))
+	}
+	return s
+}
+
+// runs summarises the runs of consecutive points for which in holds.
+func runs(points []*FormPointRow, in func(*FormPointRow) bool) StreakSummary {
+
+	var sum StreakSummary
+	var cur Streak
+	for _, p := range points {
+		if !in(p) {
+			cur = Streak{}
+			continue
+		}
+		if cur.Length == 0 {
+			cur.First, cur.From = p.Fixture, p.Timestamp
+		}
+		cur.Length++
+		cur.Last, cur.To = p.Fixture, p.Timestamp
+		if cur.Length > sum.Longest.Length {
+			sum.Longest = cur
+		}
+	}
+	sum.Current = cur
+	return sum
+}
+
+func streaks(points []*FormPointRow) Streaks {
+
+	s := Streaks{
+		Scoring: runs(points, func(p *FormPointRow) bool { return p.Goals > 0 }),
+	}
+
+	var keeper []*FormPointRow
+	for _, p := range points {
+		if p.Position == positionGoalkeeper {
+			keeper = append(keeper, p)
+		}
+	}
+	if len(keeper) > 0 {
+		cs := runs(keeper, func(p *FormPointRow) bool { return p.Minutes >= cleanSheetMinutes && p.Conceded == 0 })
+		s.CleanSheets = &cs
+	}
+	return s
+}
+
+// InForm lists the players of a league season in the best form over their
+// last window appearances, ordered by rating, by the rating above their
+// season average or by goals and assists. Players need minAppearances
+// in the window and a rating unless ordered by contributions.
+func (pm *PlayerModel) InForm(league, season, window, minAppearances int, by string, limit int) ([]*InFormRow, error) {
+
+	rows, err := pm.Repo.SelectInForm(league, season, window)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*InFormRow, 0, len(rows))
+	for _, r := range rows {
+		if r.Appearances < minAppearances {
+			continue
+		}
+		if by != FormByContributions && r.Rating == nil {
+			continue
+		}
+		r.Contributions = r.Goals + r.Assists
+		if r.Rating != nil && r.SeasonRating != nil {
+			r.Delta = *r.Rating - *r.SeasonRating
+		}
+		res = append(res, r)
+	}
+
+	value := func(r *InFormRow) float64 {
+		switch by {
+		case FormByDelta:
+			return r.Delta
+		case FormByContributions:
+			return float64(r.Contributions)
+		}
+		return *r.Rating
+	}
+	// ties are listed with the most recent appearance first
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if va, vb := value(a), value(b); va != vb {
+			return va > vb
+		}
+		if a.LastPlayed != b.LastPlayed {
+			return a.LastPlayed > b.LastPlayed
+		}
+		return a.Player < b.Player
+	})
+
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
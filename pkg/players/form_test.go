@@ -0,0 +1,60 @@
+package players
+
+import "testing"
+
+func TestCleanSheetStreaks(t *testing.T) {
+
+	keeper := func(fixture, minutes, conceded int) *FormPointRow {
+		return &FormPointRow{Fixture: fixture, Timestamp: fixture, Position: positionGoalkeeper, Minutes: minutes, Conceded: conceded}
+	}
+
+	tests := []struct {
+		name    string
+		points  []*FormPointRow
+		longest int
+		current int
+	}{
+		{
+			name:    "full matches without goals",
+			points:  []*FormPointRow{keeper(1, 90, 0), keeper(2, 90, 0), keeper(3, 90, 1), keeper(4, 90, 0)},
+			longest: 2,
+			current: 1,
+		},
+		{
+			name:    "a substitute appearance is no clean sheet",
+			points:  []*FormPointRow{keeper(1, 90, 0), keeper(2, 20, 0), keeper(3, 90, 0)},
+			longest: 1,
+			current: 1,
+		},
+		{
+			name:    "at least the minimum minutes",
+			points:  []*FormPointRow{keeper(1, cleanSheetMinutes, 0), keeper(2, cleanSheetMinutes-1, 0)},
+			longest: 1,
+			current: 0,
+		},
+		{
+			name:    "goals conceded",
+			points:  []*FormPointRow{keeper(1, 90, 2)},
+			longest: 0,
+			current: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := streaks(tt.points)
+			if s.CleanSheets == nil {
+				t.Fatal("streaks() has no clean sheets for a goalkeeper")
+			}
+			if s.CleanSheets.Longest.Length != tt.longest || s.CleanSheets.Current.Length != tt.current {
+				t.Errorf("clean sheets longest=%d current=%d, want longest=%d current=%d",
+					s.CleanSheets.Longest.Length, s.CleanSheets.Current.Length, tt.longest, tt.current)
+			}
+		})
+	}
+
+	outfield := &FormPointRow{Fixture: 1, Position: "D", Minutes: 90}
+	if s := streaks([]*FormPointRow{outfield}); s.CleanSheets != nil {
+		t.Errorf("streaks() of an outfield player has clean sheets %+v", s.CleanSheets)
+	}
+}
@@ -0,0 +1,346 @@
+package players
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	deleteTopScorers = `DELETE FROM top_scorers WHERE league = $1 AND season = $2`
+	insertTopScorer  = `INSERT INTO top_scorers (league, season, rank, player, name, team, goals, assists)
+							VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	selectTopScorers = `SELECT league, season, rank, player, name, team, goals, assists
+							FROM top_scorers WHERE league = $1 AND season = $2 ORDER BY rank`
+	// team is the one the player appeared for last
+	selectSeasonTotals = `SELECT ps.player, coalesce(pe.name, '') AS name,
+							(array_agg(ps.team ORDER BY f.timestamp DESC))[1] AS team,
+							count(*)::int AS appearances,
+							coalesce(sum(ps.minutes), 0)::int AS minutes,
+							coalesce(sum(ps.goals_scored), 0)::int AS goals,
+							coalesce(sum(ps.goals_assisted), 0)::int AS assists,
+							coalesce(sum(ps.passes_key), 0)::int AS key_passes,
+							coalesce(sum(ps.tackles), 0)::int AS tackles,
+							coalesce(sum(ps.saves), 0)::int AS saves,
+							coalesce(sum(ps.yellow), 0)::int AS yellow,
+							coalesce(sum(ps.red), 0)::int AS red,
+							count(ps.rating) FILTER (WHERE ps.rating > 0)::int AS rated,
+							(sum(ps.rating * ps.minutes) FILTER (WHERE ps.rating > 0)
+								/ nullif(sum(ps.minutes) FILTER (WHERE ps.rating > 0), 0))::float8 AS rating
+						FROM player_statistics ps
+						JOIN fixtures f ON f.id = ps.fixture
+						LEFT JOIN people pe ON pe.id = ps.player
+						WHERE ps.league = $1 AND ps.season = $2 AND ps.minutes > 0
+						GROUP BY ps.player, pe.name`
+)
+
+// leaderboard categories
+const (
+	CategoryGoals         = "goals"
+	CategoryAssists       = "assists"
+	CategoryContributions = "contributions"
+	CategoryKeyPasses     = "key_passes"
+	CategoryTackles       = "tackles"
+	CategorySaves         = "saves"
+	CategoryYellow        = "yellow"
+	CategoryRed           = "red"
+	CategoryCards         = "cards"
+	CategoryRating        = "rating"
+)
+
+// TopScorerRow is a player of the provider's top scorers of a league
+// season.
+type TopScorerRow struct {
+	League  int    `json:"league"`
+	Season  int    `json:"season"`
+	Rank    int    `json:"rank"`
+	Player  int    `json:"player"`
+	Name    string `json:"name"`
+	Team    int    `json:"team"`
+	Goals   int    `json:"goals"`
+	Assists int    `json:"assists"`
+}
+
+// SeasonTotalsRow sums the match statistics of a player in a league
+// season. Rating averages the rated appearances weighted by minutes and is
+// nil without any.
+type SeasonTotalsRow struct {
+	Player      int      `json:"player"`
+	Name        string   `json:"name"`
+	Team        int      `json:"team"`
+	Appearances int      `json:"appearances"`
+	Minutes     int      `json:"minutes"`
+	Goals       int      `json:"goals"`
+	Assists     int      `json:"assists"`
+	KeyPasses   int      `json:"key_passes"`
+	Tackles     int      `json:"tackles"`
+	Saves       int      `json:"saves"`
+	Yellow      int      `json:"yellow"`
+	Red         int      `json:"red"`
+	Rated       int      `json:"rated"`
+	Rating      *float64 `json:"rating"`
+}
+
+// value returns the total of a category, false if the player has none.
+func (t *SeasonTotalsRow) value(category string) (float64, bool) {
+
+	switch category {
+	case CategoryGoals:
+		return float64(t.Goals), true
+	case CategoryAssists:
+		return float64(t.Assists), true
+	case CategoryContributions:
+		return float64(t.Goals + t.Assists), true
+	case CategoryKeyPasses:
+		return float64(t.KeyPasses), true
+	case CategoryTackles:
+		return float64(t.Tackles), true
+	case CategorySaves:
+		return float64(t.Saves), true
+	case CategoryYellow:
+		return float64(t.Yellow), true
+	case CategoryRed:
+		return float64(t.Red), true
+	case CategoryCards:
+		return float64(t.Yellow + t.Red), true
+	case CategoryRating:
+		if t.Rating == nil {
+			return 0, false
+		}
+		return *t.Rating, true
+	}
+	return 0, false
+}
+
+// Leader is a player of a leaderboard. Players with equal values share a
+// rank, the next rank skips as many places as were shared.
+type Leader struct {
+	Rank        int     `json:"rank"`
+	Player      int     `json:"player"`
+	Name        string  `json:"name"`
+	Team        int     `json:"team"`
+	Appearances int     `json:"appearances"`
+	Minutes     int     `json:"minutes"`
+	Total       float64 `json:"total"`
+	Per90       float64 `json:"per90"`
+	Value       float64 `json:"value"`
+}
+
+// TopScorerMismatch is a provider top scorer whose goals or assists differ
+// from the sums of the match statistics.
+type TopScorerMismatch struct {
+	Player          int    `json:"player"`
+	Name            string `json:"name"`
+	ProviderGoals   int    `json:"provider_goals"`
+	Goals           int    `json:"goals"`
+	ProviderAssists int    `json:"provider_assists"`
+	Assists         int    `json:"assists"`
+}
+
+// TopScorerCheck compares the goal and assist sums with the provider's
+// top scorers.
+type TopScorerCheck struct {
+	Checked    int                  `json:"checked"`
+	Mismatches []*TopScorerMismatch `json:"mismatches"`
+}
+
+// Leaders is a leaderboard of a league season. Value is the per 90
+// minutes value if Per90 is set and the total otherwise.
+type Leaders struct {
+	League     int             `json:"league"`
+	Season     int             `json:"season"`
+	Category   string          `json:"category"`
+	Per90      bool            `json:"per90"`
+	MinMinutes int             `json:"min_minutes"`
+	Leaders    []*Leader       `json:"leaders"`
+	TopScorers *TopScorerCheck `json:"top_scorers,omitempty"`
+}
+
+// ReplaceTopScorers overwrites the top scorers of a league season in one
+// transaction.
+func (repo *Repo) ReplaceTopScorers(league, season int, rows []*TopScorerRow) (int64, error) {
+
+	ctx := context.Background()
+	tx, err := repo.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, deleteTopScorers, league, season); err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, r := range rows {
+		tag, err := tx.Exec(ctx, insertTopScorer, league, season, r.Rank, r.Player, r.Name, r.Team, r.Goals, r.Assists)
+		if err != nil {
+			return 0, err
+		}
+		n += tag.RowsAffected()
+	}
+	return n, tx.Commit(ctx)
+}
+
+func (repo *Repo) SelectTopScorers(league, season int) ([]*TopScorerRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectTopScorers, league, season)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[TopScorerRow])
+}
+
+// SelectSeasonTotals sums the match statistics of every player who played
+// in a league season.
+func (repo *Repo) SelectSeasonTotals(league, season int) ([]*SeasonTotalsRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectSeasonTotals, league, season)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[SeasonTotalsRow])
+}
+
+// FetchAndInsertTopScorers replaces the top scorers of a league season
+// with the provider's.
+func (pm *PlayerModel) FetchAndInsertTopScorers(league, season int) (int64, error) {
+
+	scorers, err := GetTopScorers(pm.Client, league, season)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([]*TopScorerRow, 0, len(*scorers))
+	for i, p := range *scorers {
+		r := &TopScorerRow{Rank: i + 1, Player: p.PlayerDetails.ID, Name: p.PlayerDetails.Name}
+		// the first statistics are those of the requested league
+		if len(p.Statistics) > 0 {
+			r.Team = p.Statistics[0].Team.ID
+			r.Goals = p.Statistics[0].Goals.Total
+			r.Assists = p.Statistics[0].Goals.Assists
+		}
+		rows = append(rows, r)
+	}
+
+	n, err := pm.Repo.ReplaceTopScorers(league, season, rows)
+	if err != nil {
+		return 0, err
+	}
+	pm.Logger.Info().Msg(fmt.Sprintf("insert top scorers: league=%d#season=%d#rows=%d", league, season, n))
+	return n, nil
+}
+
+// Leaders ranks the players of a league season by category and returns
+// the first limit ranks, all players tied on the last rank included.
+// Players with fewer than minMinutes are left out, a negative minMinutes
+// requires a third of the most minutes played by anyone for ratings and
+// per 90 values and nothing otherwise. With checkTopScorers the goal and
+// assist sums are compared with the stored provider top scorers.
+func (pm *PlayerModel) Leaders(league, season int, category string, per90 bool, minMinutes, limit int, checkTopScorers bool) (*Leaders, error) {
+
+	totals, err := pm.Repo.SelectSeasonTotals(league, season)
+	if err != nil {
+		return nil, err
+	}
+
+	res := rankLeaders(totals, league, season, category, per90, minMinutes, limit)
+
+	if checkTopScorers {
+		if res.TopScorers, err = pm.checkTopScorers(league, season, totals); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// rankLeaders ranks totals by category as described at Leaders.
+func rankLeaders(totals []*SeasonTotalsRow, league, season int, category string, per90 bool, minMinutes, limit int) *Leaders {
+
+	per90 = per90 && category != CategoryRating
+	if minMinutes < 0 {
+		minMinutes = 0
+		if per90 || category == CategoryRating {
+			for _, t := range totals {
+				if t.Minutes/3 > minMinutes {
+					minMinutes = t.Minutes / 3
+				}
+			}
+		}
+	}
+
+	res := &Leaders{League: league, Season: season, Category: category, Per90: per90, MinMinutes: minMinutes, Leaders: []*Leader{}}
+	for _, t := range totals {
+		v, ok := t.value(category)
+		if !ok || t.Minutes < minMinutes || t.Minutes == 0 {
+			continue
+		}
+		l := &Leader{
+			Player:      t.Player,
+			Name:        t.Name,
+			Team:        t.Team,
+			Appearances: t.Appearances,
+			Minutes:     t.Minutes,
+			Total:       v,
+			Per90:       v * 90 / float64(t.Minutes),
+			Value:       v,
+		}
+		if category == CategoryRating {
+			l.Per90 = 0
+		}
+		if per90 {
+			l.Value = l.Per90
+		}
+		res.Leaders = append(res.Leaders, l)
+	}
+
+	// ties are listed with the fewest minutes first
+	sort.Slice(res.Leaders, func(i, j int) bool {
+		a, b := res.Leaders[i], res.Leaders[j]
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		if a.Minutes != b.Minutes {
+			return a.Minutes < b.Minutes
+		}
+		return a.Player < b.Player
+	})
+	for i, l := range res.Leaders {
+		l.Rank = i + 1
+		if i > 0 && l.Value == res.Leaders[i-1].Value {
+			l.Rank = res.Leaders[i-1].Rank
+		}
+		if limit > 0 && l.Rank > limit {
+			res.Leaders = res.Leaders[:i]
+			break
+		}
+	}
+	return res
+}
+
+// checkTopScorers compares the stored provider top scorers with totals,
+// nil if none are stored.
+func (pm *PlayerModel) checkTopScorers(league, season int, totals []*SeasonTotalsRow) (*TopScorerCheck, error) {
+
+	scorers, err := pm.Repo.SelectTopScorers(league, season)
+	if err != nil || len(scorers) == 0 {
+		return nil, err
+	}
+
+	byPlayer := make(map[int]*SeasonTotalsRow, len(totals))
+	for _, t := range totals {
+		byPlayer[t.Player] = t
+	}
+	c := &TopScorerCheck{Checked: len(scorers), Mismatches: []*TopScorerMismatch{}}
+	for _, s := range scorers {
+		m := &TopScorerMismatch{Player: s.Player, Name: s.Name, ProviderGoals: s.Goals, ProviderAssists: s.Assists}
+		if t, ok := byPlayer[s.Player]; ok {
+			m.Goals, m.Assists = t.Goals, t.Assists
+		}
+		if m.Goals != m.ProviderGoals || m.Assists != m.ProviderAssists {
+			c.Mismatches = append(c.Mismatches, m)
+		}
+	}
+	return c, nil
+}
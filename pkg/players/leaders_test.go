@@ -0,0 +1,127 @@
+package players
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankLeaders(t *testing.T) {
+
+	rating := func(r float64) *float64 { return &r }
+
+	scorers := []*SeasonTotalsRow{
+		{Player: 4, Minutes: 900, Goals: 3},
+		{Player: 1, Minutes: 900, Goals: 5},
+		{Player: 3, Minutes: 900, Goals: 4},
+		{Player: 2, Minutes: 600, Goals: 4},
+	}
+
+	tests := []struct {
+		name       string
+		totals     []*SeasonTotalsRow
+		category   string
+		per90      bool
+		minMinutes int
+		limit      int
+		// rank and player of each leader
+		want       [][2]int
+		wantMinMin int
+	}{
+		{
+			name:     "shared ranks skip places",
+			totals:   scorers,
+			category: CategoryGoals,
+			want:     [][2]int{{1, 1}, {2, 2}, {2, 3}, {4, 4}},
+		},
+		{
+			name:     "the limit keeps players tied on the last rank",
+			totals:   scorers,
+			category: CategoryGoals,
+			limit:    2,
+			want:     [][2]int{{1, 1}, {2, 2}, {2, 3}},
+		},
+		{
+			name:     "the limit cuts ranks behind shared ones",
+			totals:   scorers,
+			category: CategoryGoals,
+			limit:    3,
+			want:     [][2]int{{1, 1}, {2, 2}, {2, 3}},
+		},
+		{
+			name: "per 90 ties list the fewest minutes first",
+			totals: []*SeasonTotalsRow{
+				{Player: 1, Minutes: 180, Goals: 2},
+				{Player: 2, Minutes: 90, Goals: 1},
+				{Player: 3, Minutes: 90, Goals: 3},
+			},
+			category: CategoryGoals,
+			per90:    true,
+			want:     [][2]int{{1, 3}, {2, 2}, {2, 1}},
+		},
+		{
+			name: "per 90 requires a third of the most minutes by default",
+			totals: []*SeasonTotalsRow{
+				{Player: 1, Minutes: 900, Goals: 1},
+				{Player: 2, Minutes: 300, Goals: 1},
+				{Player: 3, Minutes: 299, Goals: 5},
+			},
+			category:   CategoryGoals,
+			per90:      true,
+			minMinutes: -1,
+			want:       [][2]int{{1, 2}, {2, 1}},
+			wantMinMin: 300,
+		},
+		{
+			name: "ratings require a third of the most minutes by default",
+			totals: []*SeasonTotalsRow{
+				{Player: 1, Minutes: 900, Rating: rating(7.1)},
+				{Player: 2, Minutes: 299, Rating: rating(8.5)},
+				{Player: 3, Minutes: 450, Rating: rating(7.4)},
+				{Player: 4, Minutes: 600},
+			},
+			category:   CategoryRating,
+			minMinutes: -1,
+			want:       [][2]int{{1, 3}, {2, 1}},
+			wantMinMin: 300,
+		},
+		{
+			name: "totals require no minutes by default",
+			totals: []*SeasonTotalsRow{
+				{Player: 1, Minutes: 900, Goals: 1},
+				{Player: 2, Minutes: 10, Goals: 2},
+				{Player: 3, Minutes: 0, Goals: 3},
+			},
+			category:   CategoryGoals,
+			minMinutes: -1,
+			want:       [][2]int{{1, 2}, {2, 1}},
+		},
+		{
+			name: "an explicit minimum overrides the default",
+			totals: []*SeasonTotalsRow{
+				{Player: 1, Minutes: 900, Goals: 1},
+				{Player: 2, Minutes: 100, Goals: 2},
+			},
+			category:   CategoryGoals,
+			per90:      true,
+			minMinutes: 50,
+			want:       [][2]int{{1, 2}, {2, 1}},
+			wantMinMin: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := rankLeaders(tt.totals, 39, 2023, tt.category, tt.per90, tt.minMinutes, tt.limit)
+			got := make([][2]int, 0, len(res.Leaders))
+			for _, l := range res.Leaders {
+				got = append(got, [2]int{l.Rank, l.Player})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankLeaders() ranks = %v, want %v", got, tt.want)
+			}
+			if res.MinMinutes != tt.wantMinMin {
+				t.Errorf("rankLeaders() min minutes = %d, want %d", res.MinMinutes, tt.wantMinMin)
+			}
+		})
+	}
+}
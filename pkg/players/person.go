@@ -0,0 +1,185 @@
+package players
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	upsertPerson = `INSERT INTO people (id, name, firstname, lastname, birthdate, birthplace, birthcountry, nationality, height_cm, weight_kg, photo, injured)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+						ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, firstname = EXCLUDED.firstname, lastname = EXCLUDED.lastname,
+						birthdate = EXCLUDED.birthdate, birthplace = EXCLUDED.birthplace, birthcountry = EXCLUDED.birthcountry,
+						nationality = EXCLUDED.nationality, height_cm = coalesce(EXCLUDED.height_cm, people.height_cm),
+						weight_kg = coalesce(EXCLUDED.weight_kg, people.weight_kg), photo = EXCLUDED.photo,
+						injured = EXCLUDED.injured, updated_at = now()`
+	selectPerson = `SELECT id, name, firstname, lastname, birthdate, birthplace, birthcountry, nationality, height_cm, weight_kg, photo, injured
+						FROM people WHERE id = $1`
+	selectMemberships = `SELECT p.team, coalesce(t.name, '') AS team_name, p.season, s.position,
+							coalesce(s.games, 0) AS appearances, coalesce(s.minutes, 0) AS minutes,
+							coalesce(s.goals_scored, 0) AS goals, coalesce(s.goals_assisted, 0) AS assists, s.rating
+						FROM players p
+						LEFT JOIN teams t ON t.id = p.team
+						LEFT JOIN player_statistics_season s ON s.player = p.id AND s.team = p.team AND s.season = p.season
+						WHERE p.id = $1
+						ORDER BY p.season DESC, p.team`
+)
+
+// PersonRow holds the attributes of a player that do not depend on team
+// or season. Height and weight are nil if the provider has none.
+type PersonRow struct {
+	Id           int    `json:"id"`
+	Name         string `json:"name"`
+	FirstName    string `json:"firstName"`
+	LastName     string `json:"lastName"`
+	BirthDate    string `json:"birthDate"`
+	BirthPlace   string `json:"birthPlace"`
+	BirthCountry string `json:"birthCountry"`
+	Nationality  string `json:"nationality"`
+	HeightCm     *int   `json:"heightCm"`
+	WeightKg     *int   `json:"weightKg"`
+	Photo        string `json:"photo"`
+	Injured      bool   `json:"injured"`
+}
+
+// MembershipRow is a season a player was registered with a team, with the
+// season statistics if there are any.
+type MembershipRow struct {
+	Team        int      `json:"team"`
+	TeamName    string   `json:"teamName"`
+	Season      int      `json:"season"`
+	Position    *string  `json:"position"`
+	Appearances int      `json:"appearances"`
+	Minutes     int      `json:"minutes"`
+	Goals       int      `json:"goals"`
+	Assists     int      `json:"assists"`
+	Rating      *float64 `json:"rating"`
+}
+
+// Profile is a player with the team seasons, latest season first.
+type Profile struct {
+	PersonRow
+	Memberships []*MembershipRow `json:"memberships"`
+}
+
+// NewPerson converts the player details of the api.
+func NewPerson(d *PlayerDetails) *PersonRow {
+	return &PersonRow{
+		Id:           d.ID,
+		Name:         d.Name,
+		FirstName:    d.FirstName,
+		LastName:     d.LastName,
+		BirthDate:    d.Birth.Date,
+		BirthPlace:   d.Birth.Place,
+		BirthCountry: d.Birth.Country,
+		Nationality:  d.Nationality,
+		HeightCm:     ParseHeight(d.Height),
+		WeightKg:     ParseWeight(d.Weight),
+		Photo:        d.Photo,
+		Injured:      d.Injured,
+	}
+}
+
+var measure = regexp.MustCompile(`^\s*(\d+(?:[.,]\d+)?)\s*([a-zA-Z'"]*)`)
+
+// ParseHeight converts heights like "180 cm", "1.80 m" or "180" to cm.
+func ParseHeight(s string) *int {
+	v, unit, ok := parseMeasure(s)
+	if !ok {
+		return nil
+	}
+	switch unit {
+	case "", "cm":
+		// a bare value below 3 is given in meters
+		if v < 3 {
+			v *= 100
+		}
+	case "m":
+		v *= 100
+	case "in":
+		v *= 2.54
+	default:
+		return nil
+	}
+	return round(v)
+}
+
+// ParseWeight converts weights like "75 kg", "165 lbs" or "75" to kg.
+func ParseWeight(s string) *int {
+	v, unit, ok := parseMeasure(s)
+	if !ok {
+		return nil
+	}
+	switch unit {
+	case "", "kg":
+	case "lb", "lbs":
+		v *= 0.45359237
+	default:
+		return nil
+	}
+	return round(v)
+}
+
+func parseMeasure(s string) (float64, string, bool) {
+	m := measure.FindStringSubmatch(s)
+	if m == nil {
+		return 0, "", false
+	}
+	v, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if err != nil || v <= 0 {
+		return 0, "", false
+	}
+	return v, strings.ToLower(m[2]), true
+}
+
+func round(v float64) *int {
+	n := int(math.Round(v))
+	return &n
+}
+
+// values in the column order of upsertPerson
+func (p *PersonRow) values() []interface{} {
+	return []interface{}{p.Id, p.Name, p.FirstName, p.LastName, p.BirthDate, p.BirthPlace, p.BirthCountry,
+		p.Nationality, p.HeightCm, p.WeightKg, p.Photo, p.Injured}
+}
+
+// UpsertPerson writes a person or updates its attributes. Known heights
+// and weights are kept if the provider sends none.
+func (pm *Repo) UpsertPerson(p *PersonRow) (int64, error) {
+	row, err := pm.Pool.Exec(context.Background(), upsertPerson, p.values()...)
+	return row.RowsAffected(), err
+}
+
+// UpsertPeopleBatch upserts people in one transaction.
+func (pm *Repo) UpsertPeopleBatch(ps []*PersonRow) (int64, error) {
+
+	b := &pgx.Batch{}
+	for _, p := range ps {
+		b.Queue(upsertPerson, p.values()...)
+	}
+	return pm.sendBatch(b)
+}
+
+func (pm *Repo) SelectPerson(id int) (*PersonRow, error) {
+
+	rows, err := pm.Pool.Query(context.Background(), selectPerson, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[PersonRow])
+}
+
+// SelectMemberships returns the team seasons of a player, latest first.
+func (pm *Repo) SelectMemberships(id int) ([]*MembershipRow, error) {
+
+	rows, err := pm.Pool.Query(context.Background(), selectMemberships, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[MembershipRow])
+}
@@ -15,6 +15,7 @@ const (
 	playersURL      = "https://api-football-v1.p.rapidapi.com/v3/players?league=%d&season=%d&page=%d"
 	playersSquadURL = "https://api-football-v1.p.rapidapi.com/v3/players/squads?team=%d"
 	playersIdURL    = "https://api-football-v1.p.rapidapi.com/v3/players?id=%d&season=%d"
+	topScorersURL   = "https://api-football-v1.p.rapidapi.com/v3/players/topscorers?league=%d&season=%d"
 )
 
 type PlayerAPIResponse struct {
@@ -143,9 +144,9 @@ type PlayerStatistics struct {
 	Penalty     Penalty        `json:"penalty"`
 }
 
-func GetPlayers(league int, season int, paging int) (*[]Player, *shared.Paging, error) {
+func GetPlayers(c *comm.Client, league int, season int, paging int) (*[]Player, *shared.Paging, error) {
 
-	data, err := comm.GetHttpBody(playersURL, league, season, paging)
+	data, err := c.GetHttpBody(playersURL, league, season, paging)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -180,8 +181,8 @@ type Squad struct {
 	Players []PlayerSquad `json:"players"`
 }
 
-func GetPlayersByTeamId(teamId int) (*[]PlayerSquad, error) {
-	data, err := comm.GetHttpBody(playersSquadURL, teamId)
+func GetPlayersByTeamId(c *comm.Client, teamId int) (*[]PlayerSquad, error) {
+	data, err := c.GetHttpBody(playersSquadURL, teamId)
 	if err != nil {
 		return nil, err
 	}
@@ -198,8 +199,8 @@ func GetPlayersByTeamId(teamId int) (*[]PlayerSquad, error) {
 	return nil, errors.New("WTF")
 }
 
-func GetPlayerIdsByTeamId(teamId int) (*[]int, *[]PlayerSquad, error) {
-	data, err := comm.GetHttpBody(playersSquadURL, teamId)
+func GetPlayerIdsByTeamId(c *comm.Client, teamId int) (*[]int, *[]PlayerSquad, error) {
+	data, err := c.GetHttpBody(playersSquadURL, teamId)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -219,8 +220,8 @@ func GetPlayerIdsByTeamId(teamId int) (*[]int, *[]PlayerSquad, error) {
 	return &res, &sqs.Response[0].Players, nil
 }
 
-func GetPlayerById(id, season int) (*Player, error) {
-	data, err := comm.GetHttpBody(playersIdURL, id, season)
+func GetPlayerById(c *comm.Client, id, season int) (*Player, error) {
+	data, err := c.GetHttpBody(playersIdURL, id, season)
 	if err != nil {
 		return nil, err
 	}
@@ -235,3 +236,20 @@ func GetPlayerById(id, season int) (*Player, error) {
 	}
 	return &p.Response[0], nil
 }
+
+// GetTopScorers returns the provider's top scorers of a league season,
+// best first.
+func GetTopScorers(c *comm.Client, league, season int) (*[]Player, error) {
+
+	data, err := c.GetHttpBody(topScorersURL, league, season)
+	if err != nil {
+		return nil, err
+	}
+
+	p := PlayerAPIResponse{}
+	err = json.Unmarshal(data, &p)
+	if err != nil {
+		return nil, err
+	}
+	return &p.Response, nil
+}
@@ -4,25 +4,31 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/bernhardson/prefoot/pkg/metrics"
+	"github.com/bernhardson/prefoot/pkg/shared"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	insertPlayerStatistics                   = `INSERT INTO player_statistics (player, fixture, team, league, season, minutes, position, rating, captain, substitute, shots_total, shots_on, goals_scored, goals_assisted, passes_total, passes_key, accuracy, tackles, block, interceptions, duels_total, duels_won, dribbles_total,dribbles_won, yellow, red, penalty_won, penalty_committed, penalty_scored, penalty_missed, penalty_saved, saves)	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32)`
-	insertPlayer                             = `INSERT INTO players (id, team, season, firstname, lastname, birthplace, birthcountry, birthdate) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	insertPlayerStatisticsSeason             = `INSERT INTO player_statistics_season ("player","season", "team", "minutes", "position", "rating","captain", "games", "lineups", "shots_total", "shots_on", "goals_scored", "goals_assisted", "passes_total", "passes_key","accuracy", "tackles", "block", "interceptions", "duels_total", "duels_won", "dribbles_total", "dribbles_won", "yellow", "red", "penalty_won","penalty_committed", "penalty_scored", "penalty_missed", "penalty_saved", "saves") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)`
-	selectPlayer                             = `SELECT * FROM players WHERE id=$1`
-	selectPlayerStats                        = `SELECT p.id AS player_id, p.team, p.season, p.firstname, p.lastname, p.birthplace, p.birthcountry, p.birthdate, ps.fixture, ps.minutes, ps.position, ps.rating, ps.captain, ps.substitute, ps.shots_total, ps.shots_on, ps.goals_scored, ps.goals_assisted, ps.passes_total, ps.passes_key, ps.accuracy, ps.tackles, ps.block, ps.interceptions, ps.duels_total, ps.duels_won, ps.dribbles_total, ps.dribbles_won, ps.yellow, ps.red, ps.penalty_won, ps.penalty_committed, ps.penalty_scored, ps.penalty_missed, ps.penalty_saved, ps.saves FROM players p JOIN player_statistics ps ON p.team = ps.team WHERE p.team = $1`
-	selectPlayersByTeam                      = `SELECT id, team, season, firstname, lastname, birthplace, birthcountry, birthdate FROM players WHERE team = $1;`
-	selectPlayerIdsByTeam                    = `SELECT id FROM players WHERE season = $1 AND team = $2;`
-	selectPlayersByTeamLeagueSeason          = `SELECT id, team, season, firstname, lastname, birthplace, birthcountry, birthdate FROM players WHERE season=$1 AND team = $2;`
-	selectPlayerStatistics                   = `SELECT * FROM player_statistics WHERE player= ANY($1) AND fixture=ANY($2) AND team=$3`
-	selectKeyPlayerStatsByFixturesAndPlayers = `SELECT p.id AS player_id, p.firstname, p.lastname, ps.team,SUM(ps.goals_scored) AS total_goals_scored, SUM(ps.goals_assisted) AS total_goals_assisted, AVG(ps.duels_total) AS avg_duels_total, AVG(ps.duels_won) AS avg_duels_won, AVG(ps.passes_key) AS avg_key_passes, AVG(ps.rating) AS avg_rating FROM players p JOIN player_statistics ps ON p.id = ps.player where ps.player= ANY($1) and ps.fixture = ANY($2) GROUP BY ps.team, p.id, p.firstname, p.lastname;`
+	insertPlayerStatistics          = `INSERT INTO player_statistics (player, fixture, team, league, season, minutes, position, rating, captain, substitute, shots_total, shots_on, goals_scored, goals_assisted, passes_total, passes_key, accuracy, tackles, block, interceptions, duels_total, duels_won, dribbles_total,dribbles_won, yellow, red, penalty_won, penalty_committed, penalty_scored, penalty_missed, penalty_saved, saves)	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32)`
+	insertPlayer                    = `INSERT INTO players (id, team, season) VALUES ($1, $2, $3)`
+	insertPlayerStatisticsSeason    = `INSERT INTO player_statistics_season ("player","season", "team", "minutes", "position", "rating","captain", "games", "lineups", "shots_total", "shots_on", "goals_scored", "goals_assisted", "passes_total", "passes_key","accuracy", "tackles", "block", "interceptions", "duels_total", "duels_won", "dribbles_total", "dribbles_won", "yellow", "red", "penalty_won","penalty_committed", "penalty_scored", "penalty_missed", "penalty_saved", "saves") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)`
+	selectPlayer                    = `SELECT p.id, p.team, p.season, pe.firstname, pe.lastname, pe.birthplace, pe.birthcountry, pe.birthdate FROM players p JOIN people pe ON pe.id = p.id WHERE p.id=$1 ORDER BY p.season DESC LIMIT 1`
+	fromPlayerStats                 = `players p JOIN people pe ON pe.id = p.id JOIN player_statistics ps ON ps.player = p.id AND ps.team = p.team AND ps.season = p.season WHERE p.team = $1`
+	fromPlayersByTeam               = `players p JOIN people pe ON pe.id = p.id WHERE p.team = $1`
+	countPlayersByTeam              = `SELECT count(*) FROM players WHERE team = $1`
+	selectPlayerIdsByTeam           = `SELECT id FROM players WHERE season = $1 AND team = $2;`
+	selectPlayersByTeamLeagueSeason = `SELECT p.id AS "playerID", p.team, p.season, pe.firstname, pe.lastname, pe.birthplace, pe.birthcountry, pe.birthdate FROM players p JOIN people pe ON pe.id = p.id WHERE p.season=$1 AND p.team = $2;`
+	selectPlayerStatistics          = `SELECT * FROM player_statistics WHERE player= ANY($1) AND fixture=ANY($2) AND team=$3`
+	// people rather than players, which has a row per team season
+	selectKeyPlayerStatsByFixturesAndPlayers = `SELECT pe.id AS player_id, pe.firstname, pe.lastname, ps.team, AVG(ps.duels_total) AS avg_duels_total, AVG(ps.duels_won) AS avg_duels_won, AVG(ps.passes_key) AS avg_key_passes, AVG(ps.rating) AS avg_rating, ` + metrics.TotalsColumns + ` FROM people pe JOIN player_statistics ps ON pe.id = ps.player WHERE ps.player = ANY($1) AND ps.fixture = ANY($2) GROUP BY ps.team, pe.id, pe.firstname, pe.lastname;`
 )
 
+// PlayerRow is a team season of a player. The names and birth details are
+// read from people, only the team season is written to players.
 type PlayerRow struct {
-	Id           int    `json:"playerID"`
+	Id           int    `json:"playerID" db:"playerID"`
 	Team         int    `json:"team"`
 	Season       int    `json:"season"`
 	FirstName    string `json:"firstName"`
@@ -37,15 +43,15 @@ type Repo struct {
 }
 
 func (pm *Repo) Insert(p *PlayerRow) (int64, error) {
-	row, err := pm.Pool.Exec(
-		context.Background(),
-		insertPlayer,
-		p.Id, p.Team, p.Season, p.FirstName,
-		p.LastName, p.BirthPlace,
-		p.BirthCountry, p.BirthDate)
+	row, err := pm.Pool.Exec(context.Background(), insertPlayer, p.values()...)
 	return row.RowsAffected(), err
 }
 
+// values in the column order of insertPlayer
+func (p *PlayerRow) values() []interface{} {
+	return []interface{}{p.Id, p.Team, p.Season}
+}
+
 func (pm *Repo) Select(id int) (*PlayerRow, error) {
 
 	p := &PlayerRow{}
@@ -53,19 +59,42 @@ func (pm *Repo) Select(id int) (*PlayerRow, error) {
 	return p, err
 }
 
-func (pm *Repo) SelectPlayersByTeamId(id int) ([]*PlayerRow, error) {
+// PlayerColumns are the fields of player lists by their json name.
+var PlayerColumns = shared.Columns{
+	"playerID":     {Expr: "p.id", Type: "int4"},
+	"team":         {Expr: "p.team", Type: "int4"},
+	"season":       {Expr: "p.season", Type: "int4"},
+	"firstName":    {Expr: "pe.firstname", Type: "text"},
+	"lastName":     {Expr: "pe.lastname", Type: "text"},
+	"birthPlace":   {Expr: "pe.birthplace", Type: "text"},
+	"birthCountry": {Expr: "pe.birthcountry", Type: "text"},
+	"birthDate":    {Expr: "pe.birthdate", Type: "text"},
+}
+
+func (pm *Repo) SelectPlayersByTeamId(id int, page shared.PageRequest) (*shared.Page[PlayerRow], error) {
+
+	q, err := PlayerColumns.Query(page, []string{"playerID", "season"}, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := pm.Pool.Query(context.Background(), "SELECT * FROM players WHERE team = $1", id)
+	rows, err := pm.Pool.Query(context.Background(), q.Statement(fromPlayersByTeam), q.Args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	players, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[PlayerRow])
+	players, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[PlayerRow])
 	if err != nil {
 		return nil, err
 	}
-	return players, nil
+
+	var total int
+	if err := pm.Pool.QueryRow(context.Background(), countPlayersByTeam, id).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	return shared.Paginate(q, players, &total)
 }
 
 func (pm *Repo) SelectPlayerIdsBySeasonAndTeamId(season, team int) ([]int, error) {
@@ -128,17 +157,20 @@ type PlayerStatsRow struct {
 
 func (pm *Repo) InsertStats(ps *PlayerStatsRow) (int64, error) {
 
-	row, err := pm.Pool.Exec(
-		context.Background(),
-		insertPlayerStatistics,
-		ps.Player, ps.Fixture, ps.Team, ps.Season, ps.Minutes, ps.Position, ps.Rating,
+	row, err := pm.Pool.Exec(context.Background(), insertPlayerStatistics, ps.values()...)
+	return row.RowsAffected(), err
+}
+
+// values in the column order of insertPlayerStatistics
+func (ps *PlayerStatsRow) values() []interface{} {
+	return []interface{}{
+		ps.Player, ps.Fixture, ps.Team, ps.League, ps.Season, ps.Minutes, ps.Position, ps.Rating,
 		ps.Captain, ps.Substitute, ps.ShotsTotal, ps.ShotsOn, ps.GoalsScored,
 		ps.GoalsAssisted, ps.PassesTotal, ps.PassesKey, ps.Accuracy, ps.Tackles,
 		ps.Block, ps.Interceptions, ps.DuelsTotal, ps.DuelsWon,
 		ps.DribblesTotal, ps.DribblesWon, ps.Yellow, ps.Red, ps.PenaltyWon,
 		ps.PenaltyCommitted, ps.PenaltyScored, ps.PenaltyMissed, ps.PenaltySaved, ps.Saves,
-	)
-	return row.RowsAffected(), err
+	}
 }
 
 type PlayerSeasonStatsRow struct {
@@ -177,9 +209,13 @@ type PlayerSeasonStatsRow struct {
 
 func (pm *Repo) InsertSeasonStats(s *PlayerSeasonStatsRow) (int64, error) {
 
-	row, err := pm.Pool.Exec(
-		context.Background(),
-		insertPlayerStatisticsSeason,
+	row, err := pm.Pool.Exec(context.Background(), insertPlayerStatisticsSeason, s.values()...)
+	return row.RowsAffected(), err
+}
+
+// values in the column order of insertPlayerStatisticsSeason
+func (s *PlayerSeasonStatsRow) values() []interface{} {
+	return []interface{}{
 		s.PlayerID, s.Season, s.TeamID, s.Minutes,
 		s.Position, s.Rating, s.Captain, s.Appearances,
 		s.Lineups, s.TotalShots, s.ShotsOnTarget, s.TotalGoals,
@@ -188,64 +224,111 @@ func (pm *Repo) InsertSeasonStats(s *PlayerSeasonStatsRow) (int64, error) {
 		s.DuelsWon, s.DribbleAttempts, s.DribbleSuccess, s.YellowCards, s.RedCards,
 		s.PenaltiesWon, s.PenaltiesCommitted, s.PenaltiesScored, s.PenaltiesMissed,
 		s.PenaltiesSaved, s.GoalkeeperSaves,
-	)
-	return row.RowsAffected(), err
+	}
 }
 
 type PlayersJoinOnPlayerStatsRow struct {
-	PlayerID         int
-	Team             int
-	Season           int
-	FirstName        string
-	LastName         string
-	BirthPlace       string
-	BirthCountry     string
-	BirthDate        string
-	Fixture          int
-	Minutes          int
-	Position         string
-	Rating           float64
-	Captain          bool
-	Substitute       bool
-	ShotsTotal       int
-	ShotsOn          int
-	GoalsScored      int
-	GoalsAssisted    int
-	PassesTotal      int
-	PassesKey        int
-	Accuracy         int
-	Tackles          int
-	Block            int
-	Interceptions    int
-	DuelsTotal       int
-	DuelsWon         int
-	DribblesTotal    int
-	DribblesWon      int
-	Yellow           int
-	Red              int
-	PenaltyWon       int
-	PenaltyCommitted int
-	PenaltyScored    int
-	PenaltyMissed    int
-	PenaltySaved     int
-	Saves            int
+	PlayerID         int     `json:"player_id"`
+	Team             int     `json:"team"`
+	Season           int     `json:"season"`
+	FirstName        string  `json:"firstname"`
+	LastName         string  `json:"lastname"`
+	BirthPlace       string  `json:"birthplace"`
+	BirthCountry     string  `json:"birthcountry"`
+	BirthDate        string  `json:"birthdate"`
+	Fixture          int     `json:"fixture"`
+	Minutes          int     `json:"minutes"`
+	Position         string  `json:"position"`
+	Rating           float64 `json:"rating"`
+	Captain          bool    `json:"captain"`
+	Substitute       bool    `json:"substitute"`
+	ShotsTotal       int     `json:"shots_total"`
+	ShotsOn          int     `json:"shots_on"`
+	GoalsScored      int     `json:"goals_scored"`
+	GoalsAssisted    int     `json:"goals_assisted"`
+	PassesTotal      int     `json:"passes_total"`
+	PassesKey        int     `json:"passes_key"`
+	Accuracy         int     `json:"accuracy"`
+	Tackles          int     `json:"tackles"`
+	Block            int     `json:"block"`
+	Interceptions    int     `json:"interceptions"`
+	DuelsTotal       int     `json:"duels_total"`
+	DuelsWon         int     `json:"duels_won"`
+	DribblesTotal    int     `json:"dribbles_total"`
+	DribblesWon      int     `json:"dribbles_won"`
+	Yellow           int     `json:"yellow"`
+	Red              int     `json:"red"`
+	PenaltyWon       int     `json:"penalty_won"`
+	PenaltyCommitted int     `json:"penalty_committed"`
+	PenaltyScored    int     `json:"penalty_scored"`
+	PenaltyMissed    int     `json:"penalty_missed"`
+	PenaltySaved     int     `json:"penalty_saved"`
+	Saves            int     `json:"saves"`
 }
 
-func (pm *Repo) SelectPlayersAndStatisticsByTeamId(id int) (*[]*PlayersJoinOnPlayerStatsRow, error) {
+// PlayerStatsColumns are the fields of players joined with their match
+// statistics by their json name.
+var PlayerStatsColumns = shared.Columns{
+	"player_id":         {Expr: "p.id", Type: "int4"},
+	"team":              {Expr: "p.team", Type: "int4"},
+	"season":            {Expr: "p.season", Type: "int4"},
+	"firstname":         {Expr: "pe.firstname", Type: "text"},
+	"lastname":          {Expr: "pe.lastname", Type: "text"},
+	"birthplace":        {Expr: "pe.birthplace", Type: "text"},
+	"birthcountry":      {Expr: "pe.birthcountry", Type: "text"},
+	"birthdate":         {Expr: "pe.birthdate", Type: "text"},
+	"fixture":           {Expr: "ps.fixture", Type: "int4"},
+	"minutes":           {Expr: "ps.minutes", Type: "int4"},
+	"position":          {Expr: "ps.position", Type: "text"},
+	"rating":            {Expr: "ps.rating", Type: "float8"},
+	"captain":           {Expr: "ps.captain", Type: "bool"},
+	"substitute":        {Expr: "ps.substitute", Type: "bool"},
+	"shots_total":       {Expr: "ps.shots_total", Type: "int4"},
+	"shots_on":          {Expr: "ps.shots_on", Type: "int4"},
+	"goals_scored":      {Expr: "ps.goals_scored", Type: "int4"},
+	"goals_assisted":    {Expr: "ps.goals_assisted", Type: "int4"},
+	"passes_total":      {Expr: "ps.passes_total", Type: "int4"},
+	"passes_key":        {Expr: "ps.passes_key", Type: "int4"},
+	"accuracy":          {Expr: "ps.accuracy", Type: "int4"},
+	"tackles":           {Expr: "ps.tackles", Type: "int4"},
+	"block":             {Expr: "ps.block", Type: "int4"},
+	"interceptions":     {Expr: "ps.interceptions", Type: "int4"},
+	"duels_total":       {Expr: "ps.duels_total", Type: "int4"},
+	"duels_won":         {Expr: "ps.duels_won", Type: "int4"},
+	"dribbles_total":    {Expr: "ps.dribbles_total", Type: "int4"},
+	"dribbles_won":      {Expr: "ps.dribbles_won", Type: "int4"},
+	"yellow":            {Expr: "ps.yellow", Type: "int4"},
+	"red":               {Expr: "ps.red", Type: "int4"},
+	"penalty_won":       {Expr: "ps.penalty_won", Type: "int4"},
+	"penalty_committed": {Expr: "ps.penalty_committed", Type: "int4"},
+	"penalty_scored":    {Expr: "ps.penalty_scored", Type: "int4"},
+	"penalty_missed":    {Expr: "ps.penalty_missed", Type: "int4"},
+	"penalty_saved":     {Expr: "ps.penalty_saved", Type: "int4"},
+	"saves":             {Expr: "ps.saves", Type: "int4"},
+}
 
-	rows, err := pm.Pool.Query(
-		context.Background(),
-		selectPlayerStats, id)
+// SelectPlayersAndStatisticsByTeamId pages the match statistics of the
+// players of a team. The join grows with every fixture, so no total is
+// counted.
+func (pm *Repo) SelectPlayersAndStatisticsByTeamId(id int, page shared.PageRequest) (*shared.Page[PlayersJoinOnPlayerStatsRow], error) {
+
+	q, err := PlayerStatsColumns.Query(page, []string{"player_id", "season", "fixture"}, []interface{}{id})
 	if err != nil {
 		return nil, err
 	}
 
-	pls, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[PlayersJoinOnPlayerStatsRow])
+	rows, err := pm.Pool.Query(context.Background(), q.Statement(fromPlayerStats), q.Args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &pls, err
+	pls, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[PlayersJoinOnPlayerStatsRow])
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.Paginate(q, pls, nil)
 }
 
 func (pm *Repo) SelectPlayersByTeamLeagueSeason(season, team int) ([]*PlayerRow, error) {
@@ -267,17 +350,28 @@ func (pm *Repo) SelectPlayersByTeamLeagueSeason(season, team int) ([]*PlayerRow,
 	return players, nil
 }
 
+// KeyPlayerStats are the statistics of a player over a number of
+// fixtures. The averages are per appearance, Metrics holds the per 90
+// values and the rating weighted by minutes, where cameos count for less
+// than full matches.
 type KeyPlayerStats struct {
-	PlayerID           int     `json:"player_id"`
-	FirstName          string  `json:"firstname"`
-	LastName           string  `json:"lastname"`
-	Team               int     `json:"team"`
-	TotalGoalsScored   int     `json:"total_goals_scored"`
-	TotalGoalsAssisted int     `json:"total_goals_assisted"`
-	AvgDuelsTotal      float64 `json:"avg_duels_total"`
-	AvgDuelsWon        float64 `json:"avg_duels_won"`
-	AvgKeyPasses       float64 `json:"avg_key_passes"`
-	AvgRating          float64 `json:"avg_rating"`
+	PlayerID       int    `json:"player_id"`
+	FirstName      string `json:"firstname"`
+	LastName       string `json:"lastname"`
+	Team           int    `json:"team"`
+	metrics.Totals `json:"-"`
+
+	TotalGoalsScored   int              `json:"total_goals_scored" db:"-"`
+	TotalGoalsAssisted int              `json:"total_goals_assisted" db:"-"`
+	AvgDuelsTotal      float64          `json:"avg_duels_total"`
+	AvgDuelsWon        float64          `json:"avg_duels_won"`
+	AvgKeyPasses       float64          `json:"avg_key_passes"`
+	AvgRating          float64          `json:"avg_rating"`
+	Metrics            *metrics.Metrics `json:"metrics" db:"-"`
+
+	// set for players expected to miss the match
+	Unavailable       bool   `json:"unavailable" db:"-"`
+	UnavailableReason string `json:"unavailable_reason,omitempty" db:"-"`
 }
 
 func (pm *Repo) SelectPlayerStatisticsByPlayersFixturesTeam(playerIds []int, fixtureIds *[]int) ([]*KeyPlayerStats, error) {
@@ -293,5 +387,10 @@ func (pm *Repo) SelectPlayerStatisticsByPlayersFixturesTeam(playerIds []int, fix
 	if err != nil {
 		return nil, err
 	}
+	for _, s := range stats {
+		s.Metrics = s.Totals.Metrics()
+		s.TotalGoalsScored = s.Goals
+		s.TotalGoalsAssisted = s.Assists
+	}
 	return stats, nil
 }
@@ -5,71 +5,73 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/bernhardson/prefoot/pkg/shared"
 	"github.com/rs/zerolog"
 )
 
 type PlayerModel struct {
 	Logger *zerolog.Logger
+	Client *comm.Client
 	Repo   interface {
 		Insert(*PlayerRow) (int64, error)
+		Select(int) (*PlayerRow, error)
 		InsertSeasonStats(*PlayerSeasonStatsRow) (int64, error)
 		InsertStats(*PlayerStatsRow) (int64, error)
-		SelectPlayersAndStatisticsByTeamId(int) (*[]*PlayersJoinOnPlayerStatsRow, error)
-		SelectPlayersByTeamId(int) ([]*PlayerRow, error)
+		InsertBatch([]*PlayerRow) (int64, error)
+		UpsertPerson(*PersonRow) (int64, error)
+		UpsertPeopleBatch([]*PersonRow) (int64, error)
+		SelectPerson(int) (*PersonRow, error)
+		SelectMemberships(int) ([]*MembershipRow, error)
+		InsertSeasonStatsBatch([]*PlayerSeasonStatsRow) (int64, error)
+		CopyStats([]*PlayerStatsRow) (int64, []int, error)
+		SelectPlayersAndStatisticsByTeamId(int, shared.PageRequest) (*shared.Page[PlayersJoinOnPlayerStatsRow], error)
+		SelectPlayersByTeamId(int, shared.PageRequest) (*shared.Page[PlayerRow], error)
 		SelectPlayersByTeamLeagueSeason(int, int) ([]*PlayerRow, error)
 		SelectPlayerStatisticsByPlayersFixturesTeam([]int, *[]int) ([]*KeyPlayerStats, error)
 		SelectPlayerIdsBySeasonAndTeamId(int, int) ([]int, error)
+		SelectSquad(int) ([]*SquadRow, error)
+		ReplaceSquad(int, int, []PlayerSquad, *SquadDiff) error
+		ReplaceTopScorers(int, int, []*TopScorerRow) (int64, error)
+		SelectTopScorers(int, int) ([]*TopScorerRow, error)
+		SelectSeasonTotals(int, int) ([]*SeasonTotalsRow, error)
+		SelectFormSeries(int, int, int) ([]*FormPointRow, error)
+		SelectInForm(int, int, int) ([]*InFormRow, error)
 	}
 }
 
 func (pm *PlayerModel) FetchAndInsertPlayers(league int, season int) (*[]int, *[]int, error) {
 
+	run := shared.NewThroughput()
 	pgTotal := 1
 	pgCurrent := 1
 	var failedP, failedS []int
 	for i := 1; pgCurrent <= pgTotal; i++ {
-		ps, pg, err := GetPlayers(league, season, pgCurrent)
+		ps, pg, err := GetPlayers(pm.Client, league, season, pgCurrent)
 
 		if err != nil {
 			return nil, nil, err
 		}
 
+		var people []*PersonRow
+		var players []*PlayerRow
+		var stats []*PlayerSeasonStatsRow
 		for _, p := range *ps {
+			people = append(people, NewPerson(&p.PlayerDetails))
 			// player statistics only has one entry so there will be just one insert to player table
 			for _, s := range p.Statistics {
-				row, err := pm.Repo.Insert(
-					&PlayerRow{
-						Id:           p.PlayerDetails.ID,
-						Team:         s.Team.ID,
-						Season:       season,
-						FirstName:    p.PlayerDetails.FirstName,
-						LastName:     p.PlayerDetails.LastName,
-						BirthPlace:   p.PlayerDetails.Birth.Place,
-						BirthCountry: p.PlayerDetails.Birth.Country,
-						BirthDate:    p.PlayerDetails.Birth.Date,
-					},
-				)
-				//error?
-				if err != nil {
-					//sth more serious
-					if !strings.HasPrefix(err.Error(), "ERROR: duplicate key") {
-						failedP = append(failedP, p.PlayerDetails.ID)
-						pm.Logger.Err(err).Msg(err.Error())
-					} else {
-						pm.Logger.Debug().Msg(err.Error())
-					}
-					//all good
-				} else {
-					pm.Logger.Debug().Msg(fmt.Sprintf("inserted player_%d#row_%d ", p.PlayerDetails.ID, row))
-				}
+				players = append(players, &PlayerRow{
+					Id:     p.PlayerDetails.ID,
+					Team:   s.Team.ID,
+					Season: season,
+				})
 				//catch empty string ratin
 				rating, err := strconv.ParseFloat(s.Games.Rating, 32)
 				if err != nil {
 					rating = 0
 					pm.Logger.Debug().Msg(err.Error())
 				}
-				//insert season stats
-				_, err = pm.Repo.InsertSeasonStats(&PlayerSeasonStatsRow{
+				stats = append(stats, &PlayerSeasonStatsRow{
 					PlayerID:           p.PlayerDetails.ID,
 					Season:             season,
 					TeamID:             s.Team.ID,
@@ -102,24 +104,144 @@ func (pm *PlayerModel) FetchAndInsertPlayers(league int, season int) (*[]int, *[
 					PenaltiesSaved:     s.Penalty.Saved,
 					GoalkeeperSaves:    s.Goals.Saves,
 				})
-				//process any error but duplicate
-				if err != nil {
-					if !strings.HasPrefix(err.Error(), "ERROR: duplicate key") {
-						pm.Logger.Err(err).Msg(fmt.Sprintf("failed inserting player season statistics %d", p.PlayerDetails.ID))
-						failedS = append(failedS, p.PlayerDetails.ID)
-					}
-				} else {
-					pm.Logger.Debug().Msg(fmt.Sprintf("inserted player season statistics %d", p.PlayerDetails.ID))
-				}
 			}
 		}
+
+		// a page is written in one batch, if that fails single inserts
+		// find the failing rows
+		n, err := pm.Repo.UpsertPeopleBatch(people)
+		if err != nil {
+			pm.Logger.Err(err).Msg(fmt.Sprintf("upsert people batch: page=%d", pgCurrent))
+			n = pm.upsertPeople(people)
+		}
+		run.Add("people", n)
+
+		n, err = pm.Repo.InsertBatch(players)
+		if err != nil {
+			pm.Logger.Err(err).Msg(fmt.Sprintf("insert players batch: page=%d", pgCurrent))
+			n, failedP = pm.insertPlayers(players, failedP)
+		}
+		run.Add("players", n)
+
+		n, err = pm.Repo.InsertSeasonStatsBatch(stats)
+		if err != nil {
+			pm.Logger.Err(err).Msg(fmt.Sprintf("insert player season statistics batch: page=%d", pgCurrent))
+			n, failedS = pm.insertSeasonStats(stats, failedS)
+		}
+		run.Add("player_statistics_season", n)
+
 		pgTotal = pg.Total
 		pgCurrent = pg.Current + 1
 
 	}
+	pm.Logger.Info().Msg(fmt.Sprintf("ingest players: league=%d#season=%d#%s", league, season, run))
 	return &failedP, &failedS, nil
 }
 
+// SyncSquad pulls the current squad of a team and diffs it against the
+// squad of the last sync. Players new to the squad are recorded as
+// arrivals and added as members of the season, players gone as
+// departures. The first sync of a team only stores the squad as baseline,
+// without a previous squad there is nothing to tell transfers from.
+func (pm *PlayerModel) SyncSquad(team, season int) (*SquadDiff, error) {
+
+	squad, err := GetPlayersByTeamId(pm.Client, team)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := pm.Repo.SelectSquad(team)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SquadDiff{Team: team, Squad: len(*squad), Baseline: len(stored) == 0}
+	if !diff.Baseline {
+		current := make(map[int]bool, len(*squad))
+		for _, p := range *squad {
+			current[p.ID] = true
+		}
+		known := make(map[int]bool, len(stored))
+		for _, s := range stored {
+			known[s.Player] = true
+			if !current[s.Player] {
+				diff.Departures = append(diff.Departures, s.Player)
+			}
+		}
+		for _, p := range *squad {
+			if !known[p.ID] {
+				diff.Arrivals = append(diff.Arrivals, p.ID)
+			}
+		}
+	}
+
+	if err := pm.Repo.ReplaceSquad(team, season, *squad, diff); err != nil {
+		return nil, err
+	}
+	pm.Logger.Info().Msg(fmt.Sprintf("sync squad: season=%d#%s", season, diff))
+	return diff, nil
+}
+
+// upsertPeople upserts row by row and logs the failing rows.
+func (pm *PlayerModel) upsertPeople(people []*PersonRow) int64 {
+
+	var total int64
+	for _, p := range people {
+		row, err := pm.Repo.UpsertPerson(p)
+		if err != nil {
+			pm.Logger.Err(err).Msg(fmt.Sprintf("upsert person: player_%d", p.Id))
+		}
+		total += row
+	}
+	return total
+}
+
+// insertPlayers inserts row by row and appends the ids of rows failing
+// for other reasons than duplicates to failed.
+func (pm *PlayerModel) insertPlayers(players []*PlayerRow, failed []int) (int64, []int) {
+
+	var total int64
+	for _, p := range players {
+		row, err := pm.Repo.Insert(p)
+		//error?
+		if err != nil {
+			//sth more serious
+			if !strings.HasPrefix(err.Error(), "ERROR: duplicate key") {
+				failed = append(failed, p.Id)
+				pm.Logger.Err(err).Msg(err.Error())
+			} else {
+				pm.Logger.Debug().Msg(err.Error())
+			}
+			//all good
+		} else {
+			pm.Logger.Debug().Msg(fmt.Sprintf("inserted player_%d#row_%d ", p.Id, row))
+		}
+		total += row
+	}
+	return total, failed
+}
+
+// insertSeasonStats inserts row by row and appends the player ids of rows
+// failing for other reasons than duplicates to failed.
+func (pm *PlayerModel) insertSeasonStats(stats []*PlayerSeasonStatsRow, failed []int) (int64, []int) {
+
+	var total int64
+	for _, s := range stats {
+		row, err := pm.Repo.InsertSeasonStats(s)
+		//process any error but duplicate
+		if err != nil {
+			if !strings.HasPrefix(err.Error(), "ERROR: duplicate key") {
+				pm.Logger.Err(err).Msg(fmt.Sprintf("failed inserting player season statistics %d", s.PlayerID))
+				failed = append(failed, s.PlayerID)
+			}
+		} else {
+			pm.Logger.Debug().Msg(fmt.Sprintf("inserted player season statistics %d", s.PlayerID))
+		}
+		total += row
+	}
+	return total, failed
+}
+
 /* func writeToCSV(ids []int, filename string, env.Logger zerolog.Logger) {
 	// Create a new CSV file
 	file, err := os.Create(filename)
@@ -0,0 +1,105 @@
+package players
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	selectSquad = `SELECT s.player, coalesce(nullif(pe.name, ''), s.name) AS name, s.number, s.position,
+						coalesce(nullif(pe.photo, ''), s.photo) AS photo, pe.nationality, s.synced_at
+					FROM squads s LEFT JOIN people pe ON pe.id = s.player
+					WHERE s.team = $1
+					ORDER BY s.position, s.number NULLS LAST, s.player`
+	deleteSquad      = `DELETE FROM squads WHERE team = $1`
+	insertSquadEntry = `INSERT INTO squads (team, player, name, number, position, photo, synced_at)
+							VALUES ($1, $2, $3, nullif($4, 0), $5, $6, $7)`
+	insertTransfer = `INSERT INTO transfers (player, team, direction, season, detected_at) VALUES ($1, $2, $3, $4, $5)`
+	// squads only tell names and photos, known people are left as they are
+	insertSquadPerson = `INSERT INTO people (id, name, photo) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`
+	insertMembership  = `INSERT INTO players (id, team, season) SELECT id, $2, $3 FROM people WHERE id = $1
+							ON CONFLICT DO NOTHING`
+)
+
+// directions of transfer events
+const (
+	Arrival   = "in"
+	Departure = "out"
+)
+
+// SquadRow is a player of a team's current squad.
+type SquadRow struct {
+	Player      int       `json:"player"`
+	Name        string    `json:"name"`
+	Number      *int      `json:"number"`
+	Position    string    `json:"position"`
+	Photo       string    `json:"photo"`
+	Nationality *string   `json:"nationality"`
+	SyncedAt    time.Time `json:"syncedAt"`
+}
+
+// SquadDiff is the outcome of a squad sync of one team. Baseline is set on
+// the first sync, which has no arrivals or departures.
+type SquadDiff struct {
+	Team       int
+	Squad      int
+	Baseline   bool
+	Arrivals   []int
+	Departures []int
+}
+
+func (d *SquadDiff) String() string {
+	return fmt.Sprintf("team=%d#squad=%d#baseline=%t#arrivals=%v#departures=%v", d.Team, d.Squad, d.Baseline, d.Arrivals, d.Departures)
+}
+
+// SelectSquad returns the squad of a team as of the last sync.
+func (pm *Repo) SelectSquad(team int) ([]*SquadRow, error) {
+
+	rows, err := pm.Pool.Query(context.Background(), selectSquad, team)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[SquadRow])
+}
+
+// ReplaceSquad stores the synced squad of a team in one transaction. The
+// previous squad is replaced, arrivals become members of the season and
+// every arrival and departure is recorded as transfer event. A baseline
+// makes the whole squad members of the season.
+func (pm *Repo) ReplaceSquad(team, season int, squad []PlayerSquad, diff *SquadDiff) error {
+
+	ctx := context.Background()
+	tx, err := pm.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	b := &pgx.Batch{}
+	b.Queue(deleteSquad, team)
+	for _, p := range squad {
+		b.Queue(insertSquadPerson, p.ID, p.Name, p.Photo)
+		b.Queue(insertSquadEntry, team, p.ID, p.Name, p.Number, p.Position, p.Photo, now)
+	}
+	if diff.Baseline {
+		for _, p := range squad {
+			b.Queue(insertMembership, p.ID, team, season)
+		}
+	}
+	for _, id := range diff.Arrivals {
+		b.Queue(insertMembership, id, team, season)
+		b.Queue(insertTransfer, id, team, Arrival, season, now)
+	}
+	for _, id := range diff.Departures {
+		b.Queue(insertTransfer, id, team, Departure, season, now)
+	}
+
+	if err := tx.SendBatch(ctx, b).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
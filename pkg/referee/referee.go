@@ -0,0 +1,178 @@
+// Package referee normalises the referees of fixtures and aggregates their
+// matches.
+package referee
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// the no-op update makes RETURNING yield the id of known referees
+	ensureReferee = `INSERT INTO referees (name, country) VALUES ($1, $2)
+						ON CONFLICT (name, country) DO UPDATE SET name = EXCLUDED.name RETURNING id`
+	selectReferee = `SELECT id, name, country FROM referees WHERE id = $1`
+	fromReferees  = `referees WHERE ($1 = '' OR country = $1)`
+	countReferees = `SELECT count(*) FROM referees WHERE ($1 = '' OR country = $1)`
+	selectStats   = `WITH f AS (
+						SELECT f.id, f.home_goals, f.away_goals FROM fixtures f
+						WHERE f.referee_id = $1 AND ($2 = 0 OR f.season = $2)
+							AND EXISTS (SELECT 1 FROM results r WHERE r.team = f.home_team AND r.league = f.league
+								AND r.season = f.season AND r.round = f.round AND r.elapsed > 0)
+					), t AS (
+						SELECT ts.fixture, sum(ts.yellow) AS yellow, sum(ts.red) AS red, sum(ts.fouls) AS fouls
+						FROM team_statistics ts JOIN f ON f.id = ts.fixture GROUP BY ts.fixture
+					), p AS (
+						SELECT ps.fixture, sum(ps.penalty_scored + ps.penalty_missed) AS penalties
+						FROM player_statistics ps JOIN f ON f.id = ps.fixture GROUP BY ps.fixture
+					)
+					SELECT $1::int AS referee, count(*)::int AS matches,
+						count(*) FILTER (WHERE f.home_goals > f.away_goals)::int AS home_wins,
+						count(*) FILTER (WHERE f.home_goals = f.away_goals)::int AS draws,
+						count(*) FILTER (WHERE f.home_goals < f.away_goals)::int AS away_wins,
+						count(t.fixture)::int AS team_stats_matches,
+						coalesce(sum(t.yellow), 0)::int AS yellow,
+						coalesce(sum(t.red), 0)::int AS red,
+						coalesce(sum(t.fouls), 0)::int AS fouls,
+						count(p.fixture)::int AS player_stats_matches,
+						coalesce(sum(p.penalties), 0)::int AS penalties
+					FROM f LEFT JOIN t ON t.fixture = f.id LEFT JOIN p ON p.fixture = f.id`
+)
+
+// Parse splits referees given like "Felix Zwayer, Germany" into name and
+// country. The country is empty if there is none.
+func Parse(s string) (string, string) {
+
+	s = strings.TrimSpace(s)
+	i := strings.LastIndex(s, ",")
+	if i < 0 {
+		return s, ""
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+}
+
+type Repo struct {
+	Pool *pgxpool.Pool
+}
+
+type RefereeRow struct {
+	Id      int    `json:"id"`
+	Name    string `json:"name"`
+	Country string `json:"country"`
+}
+
+// StatsRow aggregates the played fixtures of a referee. Cards and fouls
+// are averaged over the matches with team statistics, penalties over
+// those with player statistics.
+type StatsRow struct {
+	Referee            int `json:"referee"`
+	Matches            int `json:"matches"`
+	HomeWins           int `json:"home_wins"`
+	Draws              int `json:"draws"`
+	AwayWins           int `json:"away_wins"`
+	TeamStatsMatches   int `json:"team_stats_matches"`
+	Yellow             int `json:"yellow"`
+	Red                int `json:"red"`
+	Fouls              int `json:"fouls"`
+	PlayerStatsMatches int `json:"player_stats_matches"`
+	Penalties          int `json:"penalties"`
+
+	HomeWinRate      float64 `json:"home_win_rate" db:"-"`
+	YellowPerGame    float64 `json:"yellow_per_game" db:"-"`
+	RedPerGame       float64 `json:"red_per_game" db:"-"`
+	CardsPerGame     float64 `json:"cards_per_game" db:"-"`
+	FoulsPerGame     float64 `json:"fouls_per_game" db:"-"`
+	PenaltiesPerGame float64 `json:"penalties_per_game" db:"-"`
+}
+
+// RefereeColumns are the fields of referee lists by their json name.
+var RefereeColumns = shared.Columns{
+	"id":      {Expr: "id", Type: "int4"},
+	"name":    {Expr: "name", Type: "text"},
+	"country": {Expr: "country", Type: "text"},
+}
+
+// Ensure returns the id of the referee given as in fixtures, it is
+// created if unknown. Empty referees have no id.
+func (repo *Repo) Ensure(referee string) (*int, error) {
+
+	name, country := Parse(referee)
+	if name == "" {
+		return nil, nil
+	}
+	var id int
+	if err := repo.Pool.QueryRow(context.Background(), ensureReferee, name, country).Scan(&id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (repo *Repo) Select(id int) (*RefereeRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectReferee, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[RefereeRow])
+}
+
+// SelectReferees pages through the referees, country filters them unless
+// empty.
+func (repo *Repo) SelectReferees(country string, page shared.PageRequest) (*shared.Page[RefereeRow], error) {
+
+	q, err := RefereeColumns.Query(page, []string{"id"}, []interface{}{country})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(context.Background(), q.Statement(fromReferees), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referees, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[RefereeRow])
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := repo.Pool.QueryRow(context.Background(), countReferees, country).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	return shared.Paginate(q, referees, &total)
+}
+
+// SelectStats aggregates the played fixtures of a referee, of one season
+// or of all seasons if season is 0.
+func (repo *Repo) SelectStats(id, season int) (*StatsRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectStats, id, season)
+	if err != nil {
+		return nil, err
+	}
+	s, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[StatsRow])
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Matches > 0 {
+		s.HomeWinRate = float64(s.HomeWins) / float64(s.Matches)
+	}
+	if s.TeamStatsMatches > 0 {
+		n := float64(s.TeamStatsMatches)
+		s.YellowPerGame = float64(s.Yellow) / n
+		s.RedPerGame = float64(s.Red) / n
+		s.CardsPerGame = float64(s.Yellow+s.Red) / n
+		s.FoulsPerGame = float64(s.Fouls) / n
+	}
+	if s.PlayerStatsMatches > 0 {
+		s.PenaltiesPerGame = float64(s.Penalties) / float64(s.PlayerStatsMatches)
+	}
+	return s, nil
+}
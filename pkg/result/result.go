@@ -8,9 +8,12 @@ import (
 )
 
 const (
-	insertResult                        = `INSERT INTO "results" ("team", "league", "round", "season", "points", "goals_for", "goals_against", "modus", elapsed) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	insertResult = `INSERT INTO "results" ("team", "league", "round", "season", "points", "goals_for", "goals_against", "modus", elapsed) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);`
+	upsertResult = `INSERT INTO "results" ("team", "league", "round", "season", "points", "goals_for", "goals_against", "modus", elapsed) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+											ON CONFLICT ("team", "round", "season") DO UPDATE SET points = EXCLUDED.points, goals_for = EXCLUDED.goals_for,
+											goals_against = EXCLUDED.goals_against, modus = EXCLUDED.modus, elapsed = EXCLUDED.elapsed;`
 	selectResult                        = `SELECT * FROM "results" WHERE team=$1`
-	selectResultByLeagueAndSeason       = `SELECT * FROM "results" WHERE league=$1 AND season=$2`
+	selectResultsByLeagueAndSeason      = `SELECT team, league, round, season, points, goals_for, goals_against, modus, elapsed FROM "results" WHERE league=$1 AND season=$2 ORDER BY round, team`
 	selectResultByLeagueSeasonTeamRound = `SELECT * FROM "results" WHERE "league"=$1 AND "season"=$2 AND "team"=$3 AND "round"=$4`
 )
 
@@ -38,22 +41,31 @@ func (sm *ResultRepo) Insert(s *ResultRow) (int64, error) {
 	return row.RowsAffected(), err
 }
 
+// Upsert inserts s or overwrites the stored result of the team's round,
+// used when a fixture's score or status changed.
+func (sm *ResultRepo) Upsert(s *ResultRow) (int64, error) {
+	row, err := sm.Pool.Exec(
+		context.Background(),
+		upsertResult,
+		s.Team, s.League, s.Round, s.Season, s.Points, s.GoalsFor, s.GoalsAgainst, s.Modus, s.Elapsed)
+	return row.RowsAffected(), err
+}
+
 func (sm *ResultRepo) Select(id int) (*ResultRow, error) {
 	s := &ResultRow{}
 	err := sm.Pool.QueryRow(context.Background(), selectResult, id).Scan(&s.Team, &s.League, &s.Round, &s.Season, &s.Points, &s.GoalsFor, &s.GoalsAgainst, &s.Modus, &s.Elapsed)
 	return s, err
 }
 
-func (sm *ResultRepo) SelectByLeagueSeason(league, season int) (*[]*ResultRow, error) {
-	rows, err := sm.Pool.Query(
-		context.Background(),
-		selectResultByLeagueAndSeason, league, season)
+// SelectByLeagueSeason returns all results of a league season, a standing
+// table is useless in pages.
+func (sm *ResultRepo) SelectByLeagueSeason(league, season int) ([]*ResultRow, error) {
+
+	rows, err := sm.Pool.Query(context.Background(), selectResultsByLeagueAndSeason, league, season)
 	if err != nil {
 		return nil, err
 	}
-
-	pls, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[ResultRow])
-	return &pls, err
+	return pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[ResultRow])
 }
 
 func (sm *ResultRepo) SelectResultByLeagueSeasonTeamRound(league, season, team, round int) (*ResultRow, error) {
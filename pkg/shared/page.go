@@ -0,0 +1,239 @@
+package shared
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PageRequest asks for one page of a list. Pages are keyset based: Cursor
+// holds the sort values of the last row of the previous page, so pages stay
+// stable while rows are inserted. Sort is a field name, prefixed with "-"
+// for descending order. Fields restricts the selected columns, fields needed
+// for sorting and paging are always selected.
+type PageRequest struct {
+	Cursor string
+	Limit  int
+	Sort   string
+	Fields []string
+}
+
+// Page is one page of a list. Next is empty on the last page, Total is nil
+// where counting is too expensive.
+type Page[T any] struct {
+	Items []*T   `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Total *int   `json:"total,omitempty"`
+}
+
+// PageError reports a page request the columns of a list do not allow.
+type PageError struct {
+	Param   string
+	Message string
+}
+
+func (e *PageError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Param, e.Message)
+}
+
+// Column is a sortable or selectable field of a list. Expr is the sql
+// expression, Type the postgres type cursor values are compared as.
+type Column struct {
+	Expr string
+	Type string
+}
+
+// Columns whitelists the fields of a list by their json name.
+type Columns map[string]Column
+
+// PageQuery holds the parts of a paged select. Select is the column list,
+// Where the keyset predicate prefixed with AND (empty on the first page),
+// OrderBy and Limit complete the statement. Args continue the arguments of
+// the statement's own filter.
+type PageQuery struct {
+	Select  string
+	Where   string
+	OrderBy string
+	Limit   int
+	Args    []interface{}
+
+	keys []string
+}
+
+// Query validates req against the columns and builds the paged select.
+// key are the fields identifying a row, they break ties between equal sort
+// values. args are the arguments of the statement's filter, keyset
+// placeholders are numbered after them.
+func (c Columns) Query(req PageRequest, key []string, args []interface{}) (*PageQuery, error) {
+
+	q := &PageQuery{Limit: req.Limit, Args: args}
+
+	desc := strings.HasPrefix(req.Sort, "-")
+	field := strings.TrimPrefix(req.Sort, "-")
+	if field != "" {
+		if _, ok := c[field]; !ok {
+			return nil, &PageError{Param: "sort", Message: fmt.Sprintf("must be one of %s", strings.Join(c.names(), ", "))}
+		}
+		q.keys = append(q.keys, field)
+	}
+	for _, k := range key {
+		if k != field {
+			q.keys = append(q.keys, k)
+		}
+	}
+
+	selected := make(map[string]bool)
+	var exprs []string
+	add := func(f string) {
+		if !selected[f] {
+			selected[f] = true
+			exprs = append(exprs, c[f].Expr+` AS "`+f+`"`)
+		}
+	}
+	for _, f := range req.Fields {
+		if _, ok := c[f]; !ok {
+			return nil, &PageError{Param: "fields", Message: fmt.Sprintf("unknown field %q", f)}
+		}
+		add(f)
+	}
+	if len(req.Fields) == 0 {
+		for _, f := range c.names() {
+			add(f)
+		}
+	}
+	for _, k := range q.keys {
+		add(k)
+	}
+	q.Select = strings.Join(exprs, ", ")
+
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+	// NULL sort values come last in both directions
+	var order []string
+	for _, k := range q.keys {
+		order = append(order, c[k].Expr+" "+dir+" NULLS LAST")
+	}
+	q.OrderBy = strings.Join(order, ", ")
+
+	if req.Cursor != "" {
+		values, err := decodeCursor(req.Cursor)
+		if err != nil || len(values) != len(q.keys) {
+			return nil, &PageError{Param: "cursor", Message: "invalid or does not match sort"}
+		}
+		// only the sort field may be NULL, the key fields identify rows
+		for i, v := range values {
+			if v == nil && (i > 0 || field == "") {
+				return nil, &PageError{Param: "cursor", Message: "invalid or does not match sort"}
+			}
+		}
+
+		placeholder := func(i int) string {
+			q.Args = append(q.Args, *values[i])
+			return fmt.Sprintf("$%d::text::%s", len(q.Args), c[q.keys[i]].Type)
+		}
+		// tuple compares the keys from i on with the cursor
+		tuple := func(i int) string {
+			if i >= len(q.keys) {
+				return "false"
+			}
+			var cols, vals []string
+			for j := i; j < len(q.keys); j++ {
+				cols = append(cols, c[q.keys[j]].Expr)
+				vals = append(vals, placeholder(j))
+			}
+			return fmt.Sprintf("(%s) %s (%s)", strings.Join(cols, ", "), cmp, strings.Join(vals, ", "))
+		}
+
+		switch {
+		case field == "":
+			q.Where = " AND " + tuple(0)
+		case values[0] == nil:
+			// the cursor is among the trailing NULLs
+			q.Where = fmt.Sprintf(" AND %s IS NULL AND %s", c[field].Expr, tuple(1))
+		default:
+			e, v := c[field].Expr, placeholder(0)
+			q.Where = fmt.Sprintf(" AND (%s %s %s OR (%s = %s AND %s) OR %s IS NULL)", e, cmp, v, e, v, tuple(1), e)
+		}
+	}
+	return q, nil
+}
+
+func (c Columns) names() []string {
+	names := make([]string, 0, len(c))
+	for n := range c {
+		names = append(names, n)
+	}
+	// map order is random, keep select lists and messages stable
+	sort.Strings(names)
+	return names
+}
+
+// Paginate turns the rows of a query built with LIMIT q.Limit+1 into a
+// page, the extra row tells whether there is a next page.
+func Paginate[T any](q *PageQuery, rows []*T, total *int) (*Page[T], error) {
+
+	p := &Page[T]{Items: rows, Total: total}
+	if len(rows) <= q.Limit {
+		return p, nil
+	}
+	p.Items = rows[:q.Limit]
+
+	// cursor values are read back from the json encoding of the last row,
+	// the column names are the json names of the row type
+	b, err := json.Marshal(p.Items[len(p.Items)-1])
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	values := make([]*string, 0, len(q.keys))
+	for _, k := range q.keys {
+		raw, ok := fields[k]
+		if !ok {
+			return nil, fmt.Errorf("paginate: row has no field %q", k)
+		}
+		if string(raw) == "null" {
+			values = append(values, nil)
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw)
+		}
+		values = append(values, &s)
+	}
+	p.Next, err = encodeCursor(values)
+	return p, err
+}
+
+// cursors are json arrays of the sort values as strings, null for NULL
+func encodeCursor(values []*string) (string, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) ([]*string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var values []*string
+	err = json.Unmarshal(b, &values)
+	return values, err
+}
+
+// Statement completes the paged select. from holds the FROM and WHERE
+// clauses of the list without the keyset predicate. One row more than the
+// limit is selected, see Paginate.
+func (q *PageQuery) Statement(from string) string {
+	return fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %d", q.Select, from, q.Where, q.OrderBy, q.Limit+1)
+}
@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Throughput counts the rows an ingestion run writes per table. Its String
+// form is meant for the run's final log line.
+type Throughput struct {
+	mu    sync.Mutex
+	start time.Time
+	rows  map[string]int64
+}
+
+func NewThroughput() *Throughput {
+	return &Throughput{start: time.Now(), rows: make(map[string]int64)}
+}
+
+// Add counts n rows written to table.
+func (t *Throughput) Add(table string, n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rows[table] += n
+}
+
+func (t *Throughput) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elapsed := time.Since(t.start)
+	tables := make([]string, 0, len(t.rows))
+	for table := range t.rows {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var b strings.Builder
+	var total int64
+	for _, table := range tables {
+		fmt.Fprintf(&b, "%s=%d#", table, t.rows[table])
+		total += t.rows[table]
+	}
+	fmt.Fprintf(&b, "rows=%d#elapsed=%s#rows_per_sec=%.1f", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+	return b.String()
+}
@@ -50,9 +50,9 @@ type Parameters struct {
 	Season string `json:"season"`
 }
 
-func GetTeams(league int, season int) (*TeamsResponse, error) {
+func GetTeams(c *comm.Client, league int, season int) (*TeamsResponse, error) {
 
-	data, err := comm.GetHttpBody(teamsURL, league, season)
+	data, err := c.GetHttpBody(teamsURL, league, season)
 	if err != nil {
 		return nil, err
 	}
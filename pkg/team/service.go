@@ -3,26 +3,33 @@ package team
 import (
 	"strconv"
 
+	"github.com/bernhardson/prefoot/pkg/comm"
+	"github.com/bernhardson/prefoot/pkg/shared"
 	"github.com/rs/zerolog"
 )
 
 type TeamModel struct {
 	Logger   *zerolog.Logger
+	Client   *comm.Client
 	TeamRepo interface {
 		Insert(*TeamRow) (int64, error)
 		Select(int) (*TeamRow, error)
 		InsertTeamSeason(*TeamSeasonRow) (int64, error)
 		SelectTeamsSeason(int, int) (*[]*TeamIds, error)
 		SelectTeamsByIds(*[]int) (*[]*TeamRow, error)
+		SelectMatchStats(int, int) ([]*MatchStatsRow, error)
 	}
 	VenuesRepo interface {
 		Insert(*VenueRow) (int64, error)
+		Select(int) (*VenueRow, error)
+		SelectVenues(string, shared.PageRequest) (*shared.Page[VenueRow], error)
+		SelectStats(int, int) (*VenueStatsRow, error)
 	}
 }
 
 func (tm *TeamModel) FetchAndInsertTeams(league int, season int) (*[]TeamVenue, error) {
 
-	resp, err := GetTeams(league, season)
+	resp, err := GetTeams(tm.Client, league, season)
 	if err != nil {
 		return nil, err
 	}
@@ -38,14 +45,21 @@ func (tm *TeamModel) FetchAndInsertTeams(league int, season int) (*[]TeamVenue,
 		if err != nil {
 			tm.Logger.Err(err).Msg(strconv.FormatInt(row, 10))
 		}
-		v := &VenueRow{
-			Id:   tv.Venue.ID,
-			Name: tv.Team.Name,
-			City: tv.Venue.City,
-		}
-		row, err = tm.VenuesRepo.Insert(v)
-		if err != nil {
-			tm.Logger.Err(err).Msg(strconv.FormatInt(row, 10))
+		// national teams and some clubs come without a venue
+		if tv.Venue.ID != 0 {
+			v := &VenueRow{
+				Id:       tv.Venue.ID,
+				Name:     tv.Venue.Name,
+				Address:  tv.Venue.Address,
+				City:     tv.Venue.City,
+				Capacity: tv.Venue.Capacity,
+				Surface:  tv.Venue.Surface,
+				Image:    tv.Venue.Image,
+			}
+			row, err = tm.VenuesRepo.Insert(v)
+			if err != nil {
+				tm.Logger.Err(err).Msg(strconv.FormatInt(row, 10))
+			}
 		}
 		ts := &TeamSeasonRow{
 			League: league,
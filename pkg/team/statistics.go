@@ -0,0 +1,285 @@
+package team
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// one row per team and fixture of a league season with the statistics
+	// of the team and of its opponent
+	selectMatchStats = `SELECT t.team, f.id AS fixture, f.timestamp, f.home_team = t.team AS home,
+							CASE WHEN f.home_team = t.team THEN f.home_goals ELSE f.away_goals END AS goals_for,
+							CASE WHEN f.home_team = t.team THEN f.away_goals ELSE f.home_goals END AS goals_against,
+							to_jsonb(t) AS own, to_jsonb(o) AS opponent
+						FROM fixtures f
+						JOIN team_statistics t ON t.fixture = f.id
+						JOIN team_statistics o ON o.fixture = f.id AND o.team <> t.team
+						WHERE f.league = $1 AND f.season = $2
+						ORDER BY f.timestamp, f.id`
+)
+
+// Stats are the team statistics of one or more matches, summed or
+// averaged. Goals are taken from the fixture, the rest from
+// team_statistics. Possession and passes_percent only make sense as
+// averages.
+type Stats struct {
+	Goals          float64 `json:"goals"`
+	ShotsTotal     float64 `json:"shots_total"`
+	ShotsOn        float64 `json:"shots_on"`
+	ShotsOff       float64 `json:"shots_off"`
+	ShotsBlocked   float64 `json:"shots_blocked"`
+	ShotsBox       float64 `json:"shots_box"`
+	ShotsOutside   float64 `json:"shots_outside"`
+	Offsides       float64 `json:"offsides"`
+	Fouls          float64 `json:"fouls"`
+	Corners        float64 `json:"corners"`
+	Possession     float64 `json:"possession"`
+	Yellow         float64 `json:"yellow"`
+	Red            float64 `json:"red"`
+	GkSaves        float64 `json:"gk_saves"`
+	PassesTotal    float64 `json:"passes_total"`
+	PassesAccurate float64 `json:"passes_accurate"`
+	PassesPercent  float64 `json:"passes_percent"`
+	ExpectedGoals  float64 `json:"expected_goals"`
+}
+
+// fields returns pointers to all values of s so they can be combined
+// without naming each of them.
+func (s *Stats) fields() []*float64 {
+	return []*float64{
+		&s.Goals, &s.ShotsTotal, &s.ShotsOn, &s.ShotsOff, &s.ShotsBlocked,
+		&s.ShotsBox, &s.ShotsOutside, &s.Offsides, &s.Fouls, &s.Corners,
+		&s.Possession, &s.Yellow, &s.Red, &s.GkSaves, &s.PassesTotal,
+		&s.PassesAccurate, &s.PassesPercent, &s.ExpectedGoals,
+	}
+}
+
+func (s *Stats) add(o *Stats) {
+	of := o.fields()
+	for i, f := range s.fields() {
+		*f += *of[i]
+	}
+}
+
+func (s Stats) scaled(factor float64) Stats {
+	for _, f := range s.fields() {
+		*f *= factor
+	}
+	return s
+}
+
+func (s Stats) minus(o Stats) Stats {
+	of := o.fields()
+	for i, f := range s.fields() {
+		*f -= *of[i]
+	}
+	return s
+}
+
+// Comparison sets the statistics of a team against those of its
+// opponents. Difference is For minus Against.
+type Comparison struct {
+	For        Stats `json:"for"`
+	Against    Stats `json:"against"`
+	Difference Stats `json:"difference"`
+}
+
+// Split aggregates a selection of the matches of a team.
+type Split struct {
+	Matches  int        `json:"matches"`
+	Wins     int        `json:"wins"`
+	Draws    int        `json:"draws"`
+	Losses   int        `json:"losses"`
+	Totals   Comparison `json:"totals"`
+	Averages Comparison `json:"averages"`
+}
+
+// Window holds the averages of the last matches up to and including a
+// fixture.
+type Window struct {
+	Fixture   int        `json:"fixture"`
+	Timestamp int        `json:"timestamp"`
+	Matches   int        `json:"matches"`
+	Averages  Comparison `json:"averages"`
+}
+
+// SeasonStats are the team statistics of a team in a league season.
+// Percentiles rank the overall averages of the team among all teams of
+// the league season from 0, the lowest, to 100, the highest value.
+type SeasonStats struct {
+	Team        int      `json:"team"`
+	League      int      `json:"league"`
+	Season      int      `json:"season"`
+	Overall     Split    `json:"overall"`
+	Home        Split    `json:"home"`
+	Away        Split    `json:"away"`
+	Last        *Split   `json:"last,omitempty"`
+	Rolling     []Window `json:"rolling,omitempty"`
+	Teams       int      `json:"teams"`
+	Percentiles struct {
+		For     Stats `json:"for"`
+		Against Stats `json:"against"`
+	} `json:"percentiles"`
+}
+
+// MatchStatsRow holds the statistics of one team and its opponent in a
+// fixture.
+type MatchStatsRow struct {
+	Team         int    `json:"team"`
+	Fixture      int    `json:"fixture"`
+	Timestamp    int    `json:"timestamp"`
+	Home         bool   `json:"home"`
+	GoalsFor     int    `json:"goals_for"`
+	GoalsAgainst int    `json:"goals_against"`
+	Own          *Stats `json:"own"`
+	Opponent     *Stats `json:"opponent"`
+}
+
+// SelectMatchStats returns the team statistics of all teams of a league
+// season, ordered by kick-off.
+func (repo *TeamRepository) SelectMatchStats(league, season int) ([]*MatchStatsRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectMatchStats, league, season)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[MatchStatsRow])
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		m.Own.Goals = float64(m.GoalsFor)
+		m.Opponent.Goals = float64(m.GoalsAgainst)
+	}
+	return matches, nil
+}
+
+// aggregate sums matches into a split.
+func aggregate(matches []*MatchStatsRow) Split {
+
+	s := Split{Matches: len(matches)}
+	for _, m := range matches {
+		switch {
+		case m.GoalsFor > m.GoalsAgainst:
+			s.Wins++
+		case m.GoalsFor == m.GoalsAgainst:
+			s.Draws++
+		default:
+			s.Losses++
+		}
+		s.Totals.For.add(m.Own)
+		s.Totals.Against.add(m.Opponent)
+	}
+	s.Totals.Difference = s.Totals.For.minus(s.Totals.Against)
+	if s.Matches > 0 {
+		f := 1 / float64(s.Matches)
+		s.Averages = Comparison{
+			For:     s.Totals.For.scaled(f),
+			Against: s.Totals.Against.scaled(f),
+		}
+		s.Averages.Difference = s.Averages.For.minus(s.Averages.Against)
+	}
+	return s
+}
+
+// percentiles ranks each value of s among all, counting ties half.
+func percentiles(s Stats, all []Stats) Stats {
+
+	var p Stats
+	if len(all) < 2 {
+		return p
+	}
+	pf := p.fields()
+	sf := s.fields()
+	for i := range pf {
+		below := 0.0
+		for _, o := range all {
+			v := *o.fields()[i]
+			if v < *sf[i] {
+				below++
+			} else if v == *sf[i] {
+				below += 0.5
+			}
+		}
+		// s itself is among all and counts as half a tie
+		*pf[i] = 100 * (below - 0.5) / float64(len(all)-1)
+	}
+	return p
+}
+
+// SeasonStats aggregates the team statistics of team in a league season.
+// If last is positive the last matches and rolling windows of that many
+// matches are included. Teams without statistics in the season return
+// pgx.ErrNoRows.
+func (tm *TeamModel) SeasonStats(team, league, season, last int) (*SeasonStats, error) {
+
+	matches, err := tm.TeamRepo.SelectMatchStats(league, season)
+	if err != nil {
+		return nil, err
+	}
+
+	byTeam := map[int][]*MatchStatsRow{}
+	for _, m := range matches {
+		byTeam[m.Team] = append(byTeam[m.Team], m)
+	}
+	own, ok := byTeam[team]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+
+	var home, away []*MatchStatsRow
+	for _, m := range own {
+		if m.Home {
+			home = append(home, m)
+		} else {
+			away = append(away, m)
+		}
+	}
+
+	res := &SeasonStats{
+		Team:    team,
+		League:  league,
+		Season:  season,
+		Overall: aggregate(own),
+		Home:    aggregate(home),
+		Away:    aggregate(away),
+		Teams:   len(byTeam),
+	}
+
+	if last > 0 {
+		from := len(own) - last
+		if from < 0 {
+			from = 0
+		}
+		l := aggregate(own[from:])
+		res.Last = &l
+
+		res.Rolling = make([]Window, 0, len(own))
+		for i, m := range own {
+			from := i + 1 - last
+			if from < 0 {
+				from = 0
+			}
+			w := aggregate(own[from : i+1])
+			res.Rolling = append(res.Rolling, Window{
+				Fixture:   m.Fixture,
+				Timestamp: m.Timestamp,
+				Matches:   w.Matches,
+				Averages:  w.Averages,
+			})
+		}
+	}
+
+	var fors, againsts []Stats
+	for _, ms := range byTeam {
+		a := aggregate(ms).Averages
+		fors = append(fors, a.For)
+		againsts = append(againsts, a.Against)
+	}
+	res.Percentiles.For = percentiles(res.Overall.Averages.For, fors)
+	res.Percentiles.Against = percentiles(res.Overall.Averages.Against, againsts)
+
+	return res, nil
+}
@@ -3,32 +3,150 @@ package team
 import (
 	"context"
 
+	"github.com/bernhardson/prefoot/pkg/shared"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	insertVenue = `INSERT INTO venues (id, name, city) VALUES ($1, $2, $3)`
+	insertVenue = `INSERT INTO venues (id, name, address, city, capacity, surface, image) VALUES ($1, $2, $3, $4, $5, $6, $7)
+					ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, address = EXCLUDED.address, city = EXCLUDED.city,
+					capacity = EXCLUDED.capacity, surface = EXCLUDED.surface, image = EXCLUDED.image`
+	insertVenueIfMissing = `INSERT INTO venues (id, name, city) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`
+	selectVenue          = `SELECT id, name, address, city, capacity, surface, image FROM venues WHERE id = $1`
+	fromVenues           = `venues WHERE ($1 = '' OR city = $1)`
+	countVenues          = `SELECT count(*) FROM venues WHERE ($1 = '' OR city = $1)`
+	// fixtures count as played once their home team has a result for the
+	// round, season 0 selects all seasons
+	selectVenueStats = `SELECT v.id AS venue, count(f.id)::int AS matches,
+							count(*) FILTER (WHERE f.home_goals > f.away_goals)::int AS home_wins,
+							count(*) FILTER (WHERE f.home_goals = f.away_goals)::int AS draws,
+							count(*) FILTER (WHERE f.home_goals < f.away_goals)::int AS away_wins,
+							coalesce(sum(f.home_goals), 0)::int AS home_goals,
+							coalesce(sum(f.away_goals), 0)::int AS away_goals
+						FROM venues v
+						LEFT JOIN fixtures f ON f.venue = v.id AND ($2 = 0 OR f.season = $2)
+							AND EXISTS (SELECT 1 FROM results r WHERE r.team = f.home_team AND r.league = f.league
+								AND r.season = f.season AND r.round = f.round AND r.elapsed > 0)
+						WHERE v.id = $1
+						GROUP BY v.id`
 )
 
-type VenueModel struct {
+type VenueRepository struct {
 	Pool *pgxpool.Pool
 }
 
 type VenueRow struct {
-	Id   int
-	Name string
-	City string
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	City     string `json:"city"`
+	Capacity int    `json:"capacity"`
+	Surface  string `json:"surface"`
+	Image    string `json:"image"`
 }
 
-func (tm *VenueModel) Insert(v *VenueRow) (int64, error) {
-	//insert venue
-	row, err := tm.Pool.Exec(
+// VenueStatsRow holds the results of the fixtures played at a venue.
+type VenueStatsRow struct {
+	Venue     int `json:"venue"`
+	Matches   int `json:"matches"`
+	HomeWins  int `json:"home_wins"`
+	Draws     int `json:"draws"`
+	AwayWins  int `json:"away_wins"`
+	HomeGoals int `json:"home_goals"`
+	AwayGoals int `json:"away_goals"`
+
+	HomeWinRate      float64 `json:"home_win_rate" db:"-"`
+	GoalsPerGame     float64 `json:"goals_per_game" db:"-"`
+	HomeGoalsPerGame float64 `json:"home_goals_per_game" db:"-"`
+	AwayGoalsPerGame float64 `json:"away_goals_per_game" db:"-"`
+}
+
+// VenueColumns are the fields of venue lists by their json name.
+var VenueColumns = shared.Columns{
+	"id":       {Expr: "id", Type: "int4"},
+	"name":     {Expr: "name", Type: "text"},
+	"address":  {Expr: "address", Type: "text"},
+	"city":     {Expr: "city", Type: "text"},
+	"capacity": {Expr: "capacity", Type: "int4"},
+	"surface":  {Expr: "surface", Type: "text"},
+	"image":    {Expr: "image", Type: "text"},
+}
+
+// Insert writes a venue or updates it if it exists.
+func (repo *VenueRepository) Insert(v *VenueRow) (int64, error) {
+
+	row, err := repo.Pool.Exec(
 		context.Background(),
 		insertVenue,
-		v.Id,
-		v.Name,
-		v.City,
+		v.Id, v.Name, v.Address, v.City, v.Capacity, v.Surface, v.Image,
 	)
+	return row.RowsAffected(), err
+}
+
+// InsertIfMissing writes a venue unless it exists. Fixtures use it for
+// venues the teams endpoint does not return, e.g. neutral grounds.
+func (repo *VenueRepository) InsertIfMissing(v *VenueRow) (int64, error) {
 
+	row, err := repo.Pool.Exec(context.Background(), insertVenueIfMissing, v.Id, v.Name, v.City)
 	return row.RowsAffected(), err
 }
+
+func (repo *VenueRepository) Select(id int) (*VenueRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectVenue, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[VenueRow])
+}
+
+// SelectVenues pages through the venues, city filters them unless empty.
+func (repo *VenueRepository) SelectVenues(city string, page shared.PageRequest) (*shared.Page[VenueRow], error) {
+
+	q, err := VenueColumns.Query(page, []string{"id"}, []interface{}{city})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.Pool.Query(context.Background(), q.Statement(fromVenues), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	venues, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByNameLax[VenueRow])
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := repo.Pool.QueryRow(context.Background(), countVenues, city).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	return shared.Paginate(q, venues, &total)
+}
+
+// SelectStats aggregates the played fixtures at a venue, of one season or
+// of all seasons if season is 0. Unknown venues return pgx.ErrNoRows.
+func (repo *VenueRepository) SelectStats(id, season int) (*VenueStatsRow, error) {
+
+	rows, err := repo.Pool.Query(context.Background(), selectVenueStats, id, season)
+	if err != nil {
+		return nil, err
+	}
+	s, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[VenueStatsRow])
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Matches > 0 {
+		n := float64(s.Matches)
+		s.HomeWinRate = float64(s.HomeWins) / n
+		s.GoalsPerGame = float64(s.HomeGoals+s.AwayGoals) / n
+		s.HomeGoalsPerGame = float64(s.HomeGoals) / n
+		s.AwayGoalsPerGame = float64(s.AwayGoals) / n
+	}
+	return s, nil
+}
@@ -0,0 +1,174 @@
+// Package versions keeps the last stored version of fetched entities so
+// ingestion only writes entities that changed, and logs which fields did.
+package versions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	selectVersion = `SELECT hash, data FROM entity_versions WHERE entity = $1 AND id = $2`
+	upsertVersion = `INSERT INTO entity_versions (entity, id, hash, data) VALUES ($1, $2, $3, $4)
+						ON CONFLICT (entity, id) DO UPDATE SET hash = EXCLUDED.hash, data = EXCLUDED.data, updated_at = now()`
+	insertChange = `INSERT INTO change_log (entity, entity_id, field, old_value, new_value) VALUES ($1, $2, $3, $4, $5)`
+)
+
+// FieldChange is one changed field, values are json encoded.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// Change describes how an entity differs from its last stored version.
+// The new version is only stored by Commit.
+type Change struct {
+	Entity  string
+	ID      int
+	Created bool
+	Fields  []FieldChange
+
+	hash []byte
+	data string
+}
+
+// Affects reports whether a field starting with one of prefixes changed.
+// New entities affect everything.
+func (c *Change) Affects(prefixes ...string) bool {
+	if c.Created {
+		return true
+	}
+	for _, f := range c.Fields {
+		for _, p := range prefixes {
+			if strings.HasPrefix(f.Field, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type Repo struct {
+	Pool *pgxpool.Pool
+}
+
+// Compare compares data, the flat field map of an entity, with the stored
+// version. It returns nil if nothing changed and the change otherwise.
+// Callers Commit the change once the entity is written, until then it
+// keeps counting as changed. Fields of new entities are not listed.
+func (repo *Repo) Compare(entity string, id int, data map[string]interface{}) (*Change, error) {
+
+	enc, err := encodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+	// json objects are encoded with sorted keys, so equal data hashes equal
+	canonical, err := json.Marshal(enc)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(canonical)
+
+	var oldHash []byte
+	var old map[string]json.RawMessage
+	err = repo.Pool.QueryRow(context.Background(), selectVersion, entity, id).Scan(&oldHash, &old)
+	created := errors.Is(err, pgx.ErrNoRows)
+	if err != nil && !created {
+		return nil, err
+	}
+	if bytes.Equal(oldHash, hash[:]) {
+		return nil, nil
+	}
+
+	change := &Change{Entity: entity, ID: id, Created: created, hash: hash[:], data: string(canonical)}
+	if !created {
+		change.Fields = diff(old, enc)
+	}
+	return change, nil
+}
+
+// Commit stores the version of c and appends its changed fields to the
+// change log in one transaction.
+func (repo *Repo) Commit(c *Change) error {
+
+	ctx := context.Background()
+	tx, err := repo.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, upsertVersion, c.Entity, c.ID, c.hash, c.data); err != nil {
+		return err
+	}
+
+	b := &pgx.Batch{}
+	for _, f := range c.Fields {
+		b.Queue(insertChange, c.Entity, c.ID, f.Field, nullJSON(f.Old), nullJSON(f.New))
+	}
+	if b.Len() > 0 {
+		if err := tx.SendBatch(ctx, b).Close(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func encodeFields(data map[string]interface{}) (map[string]json.RawMessage, error) {
+	enc := make(map[string]json.RawMessage, len(data))
+	for k, v := range data {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		enc[k] = b
+	}
+	return enc, nil
+}
+
+// diff lists the fields whose encoding differs, fields missing on one side
+// have a nil value there.
+func diff(old, new map[string]json.RawMessage) []FieldChange {
+
+	var changes []FieldChange
+	for k, v := range new {
+		if o, ok := old[k]; !ok || !jsonEqual(o, v) {
+			changes = append(changes, FieldChange{Field: k, Old: old[k], New: v})
+		}
+	}
+	for k, o := range old {
+		if _, ok := new[k]; !ok {
+			changes = append(changes, FieldChange{Field: k, Old: o})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// jsonEqual compares values independent of formatting, jsonb normalises
+// what it stores.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
+
+func nullJSON(v json.RawMessage) interface{} {
+	if v == nil {
+		return nil
+	}
+	return string(v)
+}
@@ -0,0 +1,98 @@
+package versions
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func raw(fields map[string]string) map[string]json.RawMessage {
+	m := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		m[k] = json.RawMessage(v)
+	}
+	return m
+}
+
+func TestDiff(t *testing.T) {
+
+	tests := []struct {
+		name string
+		old  map[string]string
+		new  map[string]string
+		want []FieldChange
+	}{
+		{
+			name: "equal",
+			old:  map[string]string{"goals.home": "1", "status.short": `"FT"`},
+			new:  map[string]string{"goals.home": "1", "status.short": `"FT"`},
+		},
+		{
+			name: "formatting is ignored",
+			old:  map[string]string{"players.7.shots": "[1, 2]", "lineups.1.coach": `{"a": 1, "b": 2}`},
+			new:  map[string]string{"players.7.shots": "[1,2]", "lineups.1.coach": `{"b":2,"a":1}`},
+		},
+		{
+			name: "changed values",
+			old:  map[string]string{"goals.home": "1", "goals.away": "0", "status.short": `"HT"`},
+			new:  map[string]string{"goals.home": "2", "goals.away": "0", "status.short": `"FT"`},
+			want: []FieldChange{
+				{Field: "goals.home", Old: json.RawMessage("1"), New: json.RawMessage("2")},
+				{Field: "status.short", Old: json.RawMessage(`"HT"`), New: json.RawMessage(`"FT"`)},
+			},
+		},
+		{
+			name: "added and removed fields",
+			old:  map[string]string{"players.7.rating": `"7.1"`},
+			new:  map[string]string{"players.9.rating": `"6.4"`},
+			want: []FieldChange{
+				{Field: "players.7.rating", Old: json.RawMessage(`"7.1"`)},
+				{Field: "players.9.rating", New: json.RawMessage(`"6.4"`)},
+			},
+		},
+		{
+			name: "null and missing differ",
+			old:  map[string]string{"goals.home": "null"},
+			new:  map[string]string{},
+			want: []FieldChange{
+				{Field: "goals.home", Old: json.RawMessage("null")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff(raw(tt.old), raw(tt.new))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeAffects(t *testing.T) {
+
+	changed := &Change{Fields: []FieldChange{{Field: "players.7.rating"}, {Field: "score.halftime.home"}}}
+
+	tests := []struct {
+		name     string
+		change   *Change
+		prefixes []string
+		want     bool
+	}{
+		{"created affects everything", &Change{Created: true}, []string{"goals."}, true},
+		{"matching prefix", changed, []string{"goals.", "players."}, true},
+		{"no matching prefix", changed, []string{"goals.", "status.", "teams."}, false},
+		{"prefix is not a substring match", changed, []string{"halftime."}, false},
+		{"no prefixes", changed, nil, false},
+		{"no fields", &Change{}, []string{"goals."}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.change.Affects(tt.prefixes...); got != tt.want {
+				t.Errorf("Affects(%v) = %v, want %v", tt.prefixes, got, tt.want)
+			}
+		})
+	}
+}
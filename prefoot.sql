@@ -1,10 +1,17 @@
 DROP TABLE IF EXISTS "coach_careers" CASCADE;
 DROP TABLE IF EXISTS "player_statistics" CASCADE;
 DROP TABLE IF EXISTS "players" CASCADE;
+DROP TABLE IF EXISTS "people" CASCADE;
+DROP TABLE IF EXISTS "squads" CASCADE;
+DROP TABLE IF EXISTS "transfers" CASCADE;
+DROP TABLE IF EXISTS "injuries" CASCADE;
+DROP TABLE IF EXISTS "suspension_rules" CASCADE;
 DROP TABLE IF EXISTS "player_statistics_season" CASCADE;
+DROP TABLE IF EXISTS "top_scorers" CASCADE;
 DROP TABLE IF EXISTS "formations" CASCADE;
 DROP TABLE IF EXISTS "events" CASCADE;
 DROP TABLE IF EXISTS "venues" CASCADE;
+DROP TABLE IF EXISTS "referees" CASCADE;
 DROP TABLE IF EXISTS "teams" CASCADE;
 DROP TABLE IF EXISTS "fixtures" CASCADE;
 DROP TABLE IF EXISTS "leagues" CASCADE;
@@ -13,12 +20,28 @@ DROP TABLE IF EXISTS "coaches" CASCADE;
 DROP TABLE IF EXISTS "team_statistics" CASCADE;
 DROP TABLE IF EXISTS "results" CASCADE;
 DROP TABLE IF EXISTS "seasons" CASCADE;
+DROP TABLE IF EXISTS "league_seasons" CASCADE;
 DROP TABLE IF EXISTS "rounds" CASCADE;
+DROP TABLE IF EXISTS "entity_versions" CASCADE;
 
 CREATE TABLE "leagues" (
   "id" integer PRIMARY KEY,
   "name" varchar,
-  "country" varchar
+  "type" varchar,
+  "country" varchar,
+  "logo" varchar,
+  "flag" varchar
+);
+
+-- seasons of a league with what the provider covers for them
+CREATE TABLE "league_seasons" (
+  "league" integer NOT NULL,
+  "season" integer NOT NULL,
+  "start_date" date,
+  "end_date" date,
+  "current" boolean NOT NULL DEFAULT false,
+  "coverage" jsonb NOT NULL,
+  PRIMARY KEY ("league", "season")
 );
 
 CREATE TABLE "teams" (
@@ -54,6 +77,7 @@ CREATE TABLE "fixtures" (
   "league" integer,
   "round" integer,
   "referee" varchar,
+  "referee_id" integer,
   "timezone" varchar,
   "timestamp" integer,
   "venue" integer,
@@ -80,10 +104,24 @@ CREATE INDEX rounds_timestamp ON rounds("timestamp");
 
 CREATE TABLE "venues" (
   "id" integer PRIMARY KEY,
-  "name" varchar,
-  "city" varchar
+  "name" varchar NOT NULL DEFAULT '',
+  "address" varchar NOT NULL DEFAULT '',
+  "city" varchar NOT NULL DEFAULT '',
+  "capacity" integer NOT NULL DEFAULT 0,
+  "surface" varchar NOT NULL DEFAULT '',
+  "image" varchar NOT NULL DEFAULT ''
+);
+
+-- referees normalised from the fixtures' "Name, Country" strings
+CREATE TABLE "referees" (
+  "id" SERIAL PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "country" varchar NOT NULL DEFAULT '',
+  UNIQUE ("name", "country")
 );
 
+CREATE INDEX fixtures_referee ON fixtures("referee_id");
+
 CREATE TABLE "events" (
   "id" integer PRIMARY KEY,
   "fixture" integer,
@@ -118,18 +156,87 @@ CREATE TABLE "formations" (
   PRIMARY KEY("fixture", "team")
 );
 
+-- the team seasons of a player, names and birth details are in people
 CREATE TABLE "players" (
   "id" integer,
   "team" integer,
   "season" integer,
-  "firstname" varchar,
-  "lastname" varchar,
-  "birthplace" varchar,
-  "birthcountry" varchar,
-  "birthdate" varchar
   PRIMARY KEY ("id", "team", "season")
 );
 
+-- a player independent of team and season
+CREATE TABLE "people" (
+  "id" integer PRIMARY KEY,
+  "name" varchar NOT NULL DEFAULT '',
+  "firstname" varchar NOT NULL DEFAULT '',
+  "lastname" varchar NOT NULL DEFAULT '',
+  "birthdate" varchar NOT NULL DEFAULT '',
+  "birthplace" varchar NOT NULL DEFAULT '',
+  "birthcountry" varchar NOT NULL DEFAULT '',
+  "nationality" varchar NOT NULL DEFAULT '',
+  "height_cm" integer,
+  "weight_kg" integer,
+  "photo" varchar NOT NULL DEFAULT '',
+  "injured" boolean NOT NULL DEFAULT false,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- current squads as of the last squad sync
+CREATE TABLE "squads" (
+  "team" integer NOT NULL,
+  "player" integer NOT NULL,
+  "name" varchar NOT NULL DEFAULT '',
+  "number" integer,
+  "position" varchar NOT NULL DEFAULT '',
+  "photo" varchar NOT NULL DEFAULT '',
+  "synced_at" TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY ("team", "player")
+);
+
+-- arrivals and departures found by squad syncs, dated by the sync that
+-- found them since the squads endpoint has no transfer dates
+CREATE TABLE "transfers" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "player" integer NOT NULL,
+  "team" integer NOT NULL,
+  "direction" varchar NOT NULL CHECK ("direction" IN ('in', 'out')),
+  "season" integer NOT NULL,
+  "detected_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX transfers_player_idx ON "transfers" ("player", "detected_at");
+
+-- players missing or doubtful for a fixture as reported by the provider
+CREATE TABLE "injuries" (
+  "player" integer NOT NULL,
+  "fixture" integer NOT NULL,
+  "team" integer NOT NULL,
+  "league" integer NOT NULL,
+  "season" integer NOT NULL,
+  "name" varchar NOT NULL DEFAULT '',
+  "type" varchar NOT NULL DEFAULT '',
+  "reason" varchar NOT NULL DEFAULT '',
+  PRIMARY KEY ("player", "fixture")
+);
+
+CREATE INDEX injuries_fixture_idx ON "injuries" ("fixture");
+
+-- reaching yellow_limits[i] yellow cards bans for yellow_bans[i] fixtures,
+-- leagues without a row use the default of the availability package
+CREATE TABLE "suspension_rules" (
+  "league" integer PRIMARY KEY,
+  "yellow_limits" integer[] NOT NULL,
+  "yellow_bans" integer[] NOT NULL,
+  "red_ban" integer NOT NULL
+);
+
+INSERT INTO "suspension_rules" ("league", "yellow_limits", "yellow_bans", "red_ban") VALUES
+  (39, '{5,10,15}', '{1,2,3}', 1),
+  (78, '{5,10,15}', '{1,1,1}', 1),
+  (140, '{5,10,15}', '{1,1,1}', 1),
+  (135, '{5,10,15}', '{1,1,1}', 1),
+  (61, '{5,10,15}', '{1,1,1}', 1);
+
 CREATE TABLE "player_statistics" (
   "player" integer,
   "fixture" integer,
@@ -166,6 +273,20 @@ CREATE TABLE "player_statistics" (
   PRIMARY KEY("player", "fixture")
 );
 
+-- the provider's top scorers of a league season, used to cross-check
+-- the leaderboards computed from player_statistics
+CREATE TABLE "top_scorers" (
+  "league" integer NOT NULL,
+  "season" integer NOT NULL,
+  "rank" integer NOT NULL,
+  "player" integer NOT NULL,
+  "name" varchar NOT NULL DEFAULT '',
+  "team" integer,
+  "goals" integer NOT NULL DEFAULT 0,
+  "assists" integer NOT NULL DEFAULT 0,
+  PRIMARY KEY ("league", "season", "player")
+);
+
 CREATE TABLE "player_statistics_season" (
   "player" integer,
   "season" integer,
@@ -227,12 +348,20 @@ CREATE TABLE "team_statistics" (
 
 CREATE TABLE "coaches" (
   "id" integer PRIMARY KEY,
-  "name" varchar
+  "name" varchar,
+  "firstname" varchar NOT NULL DEFAULT '',
+  "lastname" varchar NOT NULL DEFAULT '',
+  "birthdate" varchar NOT NULL DEFAULT '',
+  "nationality" varchar NOT NULL DEFAULT '',
+  "photo" varchar NOT NULL DEFAULT '',
+  "team" integer
 );
 
+-- "end" is NULL for the current job
 CREATE TABLE "coach_careers" (
   "coach" integer ,
   "team" integer,
+  "team_name" varchar NOT NULL DEFAULT '',
   "start" timestamp,
   "end" timestamp NULL,
   PRIMARY KEY ("coach", "team", "start")
@@ -251,13 +380,99 @@ CREATE TABLE users (
     name VARCHAR(255) NOT NULL,
     email VARCHAR(255) NOT NULL,
     hashed_password CHAR(60) NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT false,
+    admin BOOLEAN NOT NULL DEFAULT false,
     created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
 
+CREATE TABLE tokens (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    hash BYTEA NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expiry TIMESTAMPTZ NOT NULL,
+    last_used TIMESTAMPTZ NULL
+);
+
+CREATE UNIQUE INDEX tokens_hash_idx ON tokens (hash);
+CREATE INDEX tokens_user_id_idx ON tokens (user_id);
+
+CREATE TABLE user_tokens (
+    hash BYTEA PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    expiry TIMESTAMPTZ NOT NULL,
+    used TIMESTAMPTZ NULL
+);
+
+CREATE INDEX user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+
+CREATE TABLE login_attempts (
+    key VARCHAR(320) PRIMARY KEY,
+    failures integer NOT NULL,
+    last_failure TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ NULL
+);
+
+CREATE UNLOGGED TABLE rate_limits (
+    key VARCHAR(320) PRIMARY KEY,
+    tokens FLOAT NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated TIMESTAMPTZ NOT NULL
+);
+
+-- the archive is kept when the tables above are rebuilt, reprocessing fills
+-- them again from it
+CREATE TABLE IF NOT EXISTS raw_responses (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint VARCHAR NOT NULL,
+    params JSONB NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    body JSONB NOT NULL,
+    hash BYTEA NOT NULL,
+    UNIQUE (endpoint, params, hash)
+);
+
+CREATE INDEX IF NOT EXISTS raw_responses_lookup_idx ON raw_responses (endpoint, params, fetched_at DESC);
+
+-- last written version of fetched entities, dropped with the tables it
+-- describes so a rebuild writes everything again
+CREATE TABLE "entity_versions" (
+    entity VARCHAR NOT NULL,
+    id integer NOT NULL,
+    hash BYTEA NOT NULL,
+    data JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (entity, id)
+);
+
+-- fields that changed between two versions of an entity, e.g. score
+-- corrections, rating updates or status flips
+CREATE TABLE IF NOT EXISTS change_log (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR NOT NULL,
+    entity_id integer NOT NULL,
+    field VARCHAR NOT NULL,
+    old_value JSONB,
+    new_value JSONB,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS change_log_entity_idx ON change_log (entity, entity_id, changed_at);
+
+ALTER TABLE "league_seasons" ADD FOREIGN KEY ("league") REFERENCES "leagues" ("id") DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE "fixtures" ADD FOREIGN KEY ("referee_id") REFERENCES "referees" ("id") DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE "fixtures" ADD FOREIGN KEY ("venue") REFERENCES "venues" ("id") DEFERRABLE INITIALLY DEFERRED;
 ALTER TABLE "fixtures" ADD FOREIGN KEY ("home_team") REFERENCES "teams" ("id") DEFERRABLE INITIALLY DEFERRED;
 ALTER TABLE "fixtures" ADD FOREIGN KEY ("away_team") REFERENCES "teams" ("id") DEFERRABLE INITIALLY DEFERRED;
 ALTER TABLE "events" ADD FOREIGN KEY ("fixture") REFERENCES "fixtures" ("id") DEFERRABLE INITIALLY DEFERRED;
 ALTER TABLE "formations" ADD FOREIGN KEY ("fixture") REFERENCES "fixtures" ("id") DEFERRABLE INITIALLY DEFERRED;
-ALTER TABLE "player_statistics" ADD FOREIGN KEY ("player") REFERENCES "players" ("id") DEFERRABLE INITIALLY DEFERRED;
\ No newline at end of file
+ALTER TABLE "players" ADD FOREIGN KEY ("id") REFERENCES "people" ("id") DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE "player_statistics" ADD FOREIGN KEY ("player") REFERENCES "people" ("id") DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE "squads" ADD FOREIGN KEY ("player") REFERENCES "people" ("id") DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE "squads" ADD FOREIGN KEY ("team") REFERENCES "teams" ("id") DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE "transfers" ADD FOREIGN KEY ("player") REFERENCES "people" ("id") DEFERRABLE INITIALLY DEFERRED;
\ No newline at end of file